@@ -0,0 +1,317 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// DefaultQueueSize is the number of debug messages that an AsyncWriter will
+// queue when AsyncOptions.QueueSize is not set.
+const DefaultQueueSize = 1024
+
+// DropPolicy determines what an AsyncWriter does when a debug message is
+// written and the queue is full.
+type DropPolicy int
+
+const (
+	// BlockWhenFull blocks the writing goroutine until there is room in the
+	// queue. No debug messages are dropped.
+	BlockWhenFull DropPolicy = iota
+
+	// DropNewest discards the debug message that is being written and keeps
+	// the messages that are already in the queue.
+	DropNewest
+
+	// DropOldest discards the oldest debug message in the queue to make room
+	// for the debug message that is being written.
+	DropOldest
+)
+
+// String returns the name of the drop policy.
+func (p DropPolicy) String() string {
+	switch p {
+	case BlockWhenFull:
+		return "block"
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	}
+
+	return "DropPolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// AsyncOptions configures an AsyncWriter.
+type AsyncOptions struct {
+	// QueueSize is the maximum number of debug messages that can be waiting
+	// to be written. If QueueSize is zero, DefaultQueueSize is used.
+	QueueSize int
+
+	// DropPolicy determines what happens when a debug message is written and
+	// the queue is full.
+	DropPolicy DropPolicy
+}
+
+// AsyncWriter queues debug messages and writes them to the underlying Writer
+// on a background goroutine so that writing a debug message does not wait
+// for the Process Monitor device. Messages are written in the order that
+// they were queued. When messages are dropped because the queue is full, a
+// "N messages dropped" message is written in their place.
+//
+// AsyncWriter is safe for concurrent use. Call Close to write the queued
+// messages and stop the background goroutine.
+type AsyncWriter struct {
+	w      Writer
+	size   int
+	policy DropPolicy
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	notEmpty *sync.Cond
+	queue    []asyncMessage
+	lost     int
+	dropped  uint64
+	queued   uint64
+	written  uint64
+	progress chan struct{}
+	err      error
+	closed   bool
+	done     chan struct{}
+}
+
+// asyncMessage is a debug message that is waiting to be written. dropped is
+// the number of messages that were lost immediately before this message.
+type asyncMessage struct {
+	message    string
+	dropped    int
+	markerOnly bool
+}
+
+// NewAsyncWriter returns an AsyncWriter that writes debug messages to w and
+// starts its background goroutine.
+func NewAsyncWriter(w Writer, opts AsyncOptions) *AsyncWriter {
+	size := opts.QueueSize
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	aw := &AsyncWriter{
+		w:        w,
+		size:     size,
+		policy:   opts.DropPolicy,
+		progress: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	aw.notFull = sync.NewCond(&aw.mu)
+	aw.notEmpty = sync.NewCond(&aw.mu)
+	go aw.run()
+	return aw
+}
+
+// Write queues p to be written as a debug message.
+func (aw *AsyncWriter) Write(p []byte) (n int, err error) {
+	if err = aw.enqueue(string(p)); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString queues s to be written as a debug message.
+func (aw *AsyncWriter) WriteString(s string) (n int, err error) {
+	if err = aw.enqueue(s); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// Dropped returns the number of debug messages that have been discarded
+// because the queue was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	return aw.dropped
+}
+
+// Flush waits until every debug message that was queued before Flush was
+// called has been written, or until ctx is done. Flush returns the first
+// error that the underlying Writer returned since the previous call to
+// Flush, or ctx.Err() if ctx is done first.
+func (aw *AsyncWriter) Flush(ctx context.Context) error {
+	aw.mu.Lock()
+	aw.queueMarker()
+	target := aw.queued
+	for aw.written < target {
+		progress := aw.progress
+		aw.mu.Unlock()
+		select {
+		case <-progress:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		aw.mu.Lock()
+	}
+
+	err := aw.err
+	aw.err = nil
+	aw.mu.Unlock()
+	return err
+}
+
+// Close writes the queued debug messages and stops the background
+// goroutine. Writes to the AsyncWriter after Close return ErrClosed. Close
+// does not close the underlying Writer.
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if aw.closed {
+		aw.mu.Unlock()
+		return nil
+	}
+
+	aw.closed = true
+	aw.queueMarker()
+	aw.notFull.Broadcast()
+	aw.notEmpty.Broadcast()
+	aw.mu.Unlock()
+
+	<-aw.done
+
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	err := aw.err
+	aw.err = nil
+	return err
+}
+
+func (aw *AsyncWriter) enqueue(s string) error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	for !aw.closed && len(aw.queue) >= aw.size {
+		switch aw.policy {
+		case DropNewest:
+			aw.dropped++
+			aw.lost++
+			return nil
+		case DropOldest:
+			oldest := aw.queue[0]
+			aw.queue = aw.queue[1:]
+			aw.written++
+			lost := oldest.dropped
+			if !oldest.markerOnly {
+				aw.dropped++
+				lost++
+			}
+
+			// The dropped messages were queued before the new head of the
+			// queue, so the marker is written before it.
+			if 0 < len(aw.queue) {
+				aw.queue[0].dropped += lost
+			} else {
+				aw.lost += lost
+			}
+		default:
+			aw.notFull.Wait()
+		}
+	}
+
+	if aw.closed {
+		return ErrClosed
+	}
+
+	aw.push(asyncMessage{message: s, dropped: aw.lost})
+	return nil
+}
+
+// queueMarker queues a "messages dropped" marker for messages that were
+// dropped after the last queued message so that the loss is reported when
+// the queue is flushed.
+func (aw *AsyncWriter) queueMarker() {
+	if 0 < aw.lost {
+		aw.push(asyncMessage{dropped: aw.lost, markerOnly: true})
+	}
+}
+
+func (aw *AsyncWriter) push(m asyncMessage) {
+	aw.queue = append(aw.queue, m)
+	aw.lost = 0
+	aw.queued++
+	aw.notEmpty.Signal()
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	for {
+		for 0 == len(aw.queue) && !aw.closed {
+			aw.notEmpty.Wait()
+		}
+
+		if 0 == len(aw.queue) {
+			return
+		}
+
+		m := aw.queue[0]
+		aw.queue = aw.queue[1:]
+		aw.notFull.Signal()
+		aw.mu.Unlock()
+
+		err := aw.write(m)
+
+		aw.mu.Lock()
+		if nil == aw.err {
+			aw.err = err
+		}
+
+		aw.written++
+		close(aw.progress)
+		aw.progress = make(chan struct{})
+	}
+}
+
+func (aw *AsyncWriter) write(m asyncMessage) (err error) {
+	if 0 < m.dropped {
+		_, err = fmt.Fprintf(aw.w, "%d messages dropped", m.dropped)
+	}
+
+	if !m.markerOnly {
+		if _, e := aw.w.WriteString(m.message); nil == err {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,181 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"context"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// gatedWriter records debug messages but blocks each write until the test
+// releases it.
+type gatedWriter struct {
+	recordingWriter
+	started chan string
+	release chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan string, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) WriteString(s string) (n int, err error) {
+	w.started <- s
+	<-w.release
+	return w.recordingWriter.WriteString(s)
+}
+
+var _ = Describe("AsyncWriter", func() {
+	var recorder *recordingWriter
+	var writer *AsyncWriter
+
+	Context("when the queue is not full", func() {
+		BeforeEach(func() {
+			recorder = &recordingWriter{}
+			writer = NewAsyncWriter(recorder, AsyncOptions{})
+		})
+
+		AfterEach(func() {
+			writer.Close()
+		})
+
+		It("writes the messages in order", func() {
+			for _, s := range []string{"one", "two", "three"} {
+				io.WriteString(writer, s)
+			}
+
+			Expect(writer.Flush(context.Background())).To(Succeed())
+			Expect(recorder.Messages()).To(Equal([]string{"one", "two", "three"}))
+		})
+
+		It("returns the length of the message", func() {
+			n, err := writer.Write([]byte("Hello, World!"))
+			Expect(n).To(Equal(13))
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("when the queue is full", func() {
+		var gate *gatedWriter
+
+		newWriter := func(policy DropPolicy) {
+			gate = newGatedWriter()
+			writer = NewAsyncWriter(gate, AsyncOptions{
+				QueueSize:  2,
+				DropPolicy: policy,
+			})
+			io.WriteString(writer, "first")
+			Eventually(gate.started).Should(Receive())
+		}
+
+		AfterEach(func() {
+			close(gate.release)
+			writer.Close()
+		})
+
+		It("drops the newest messages", func() {
+			newWriter(DropNewest)
+			for _, s := range []string{"a", "b", "c", "d"} {
+				io.WriteString(writer, s)
+			}
+
+			close(gate.release)
+			Expect(writer.Flush(context.Background())).To(Succeed())
+			gate.release = make(chan struct{})
+			Expect(gate.Messages()).To(Equal(
+				[]string{"first", "a", "b", "2 messages dropped"}))
+			Expect(writer.Dropped()).To(Equal(uint64(2)))
+		})
+
+		It("drops the oldest messages", func() {
+			newWriter(DropOldest)
+			for _, s := range []string{"a", "b", "c", "d"} {
+				io.WriteString(writer, s)
+			}
+
+			close(gate.release)
+			Expect(writer.Flush(context.Background())).To(Succeed())
+			gate.release = make(chan struct{})
+			Expect(gate.Messages()).To(Equal(
+				[]string{"first", "2 messages dropped", "c", "d"}))
+			Expect(writer.Dropped()).To(Equal(uint64(2)))
+		})
+
+		It("blocks until there is room in the queue", func() {
+			newWriter(BlockWhenFull)
+			io.WriteString(writer, "a")
+			io.WriteString(writer, "b")
+
+			done := make(chan struct{})
+			go func() {
+				io.WriteString(writer, "c")
+				close(done)
+			}()
+
+			Consistently(done).ShouldNot(BeClosed())
+			close(gate.release)
+			Eventually(done).Should(BeClosed())
+			Expect(writer.Flush(context.Background())).To(Succeed())
+			gate.release = make(chan struct{})
+			Expect(gate.Messages()).To(Equal([]string{"first", "a", "b", "c"}))
+			Expect(writer.Dropped()).To(BeZero())
+		})
+
+		It("stops flushing when the context is done", func() {
+			newWriter(BlockWhenFull)
+			ctx, cancel := context.WithTimeout(context.Background(),
+				10*time.Millisecond)
+			defer cancel()
+			Expect(writer.Flush(ctx)).To(Equal(context.DeadlineExceeded))
+		})
+	})
+
+	Describe("Close", func() {
+		BeforeEach(func() {
+			recorder = &recordingWriter{}
+			writer = NewAsyncWriter(recorder, AsyncOptions{})
+		})
+
+		It("writes the queued messages", func() {
+			io.WriteString(writer, "one")
+			io.WriteString(writer, "two")
+			Expect(writer.Close()).To(Succeed())
+			Expect(recorder.Messages()).To(Equal([]string{"one", "two"}))
+		})
+
+		It("rejects later writes", func() {
+			writer.Close()
+			_, err := io.WriteString(writer, "one")
+			Expect(err).To(Equal(ErrClosed))
+		})
+	})
+})
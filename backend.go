@@ -0,0 +1,307 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mfcollins3/go-debugger"
+)
+
+// BackendEnvironmentVariable is the name of the environment variable that
+// selects the backends that ProcessMonitor writes to. The value is a
+// comma-separated list of backend names. A backend name may be followed by a
+// colon and an argument that is passed to the backend factory, for example
+// "procmon,stderr,file:/tmp/debug.log".
+const BackendEnvironmentVariable = "PROCMON_BACKEND"
+
+// Backend delivers debug messages to a destination such as the Process
+// Monitor log. Messages have already been fit to the message size limit when
+// they are passed to Write.
+type Backend interface {
+	// Write writes a single debug message.
+	Write(message string) error
+
+	// Close releases the resources that are used by the backend.
+	Close() error
+}
+
+// BackendFactory creates a Backend. arg is the text that follows the colon
+// in the backend specification, or an empty string if there is no colon.
+type BackendFactory func(arg string) (Backend, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{
+		"debugger": openDebuggerBackend,
+		"file":     openFileBackend,
+		"null":     openNullBackend,
+		"procmon":  openProcessMonitor,
+		"stderr":   openStderrBackend,
+	}
+)
+
+// RegisterBackend makes a backend available by name to OpenBackend.
+// Registering a backend with the same name as an existing backend replaces
+// the existing backend, and registering a nil factory removes it. If the
+// PROCMON_BACKEND environment variable names the backend, the connection
+// that was opened from the environment variable is reopened so that the
+// backend can be selected by the environment variable even though it is
+// registered after the procmon package is initialized.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	if nil == factory {
+		delete(backends, name)
+	} else {
+		backends[name] = factory
+	}
+
+	backendsMu.Unlock()
+	if nil != factory {
+		reopenEnvironmentBackend(name)
+	}
+}
+
+// specNames returns true if the backend specification lists the backend.
+func specNames(spec, name string) bool {
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if i := strings.Index(s, ":"); 0 <= i {
+			s = s[:i]
+		}
+
+		if name == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backends returns the sorted names of the registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// OpenBackend opens the backends that are listed in spec. spec uses the same
+// format as the PROCMON_BACKEND environment variable. If more than one
+// backend is listed, the returned Backend writes each debug message to every
+// backend. If any backend cannot be opened, the backends that were opened
+// are closed and an error is returned.
+func OpenBackend(spec string) (Backend, error) {
+	b, err := openBackends(spec)
+	if nil != err {
+		if nil != b {
+			b.Close()
+		}
+
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// NewWriter returns a Writer that writes debug messages to b. Messages are
+// passed through the current MessageSizer before they are written.
+func NewWriter(b Backend) Writer {
+	return &processMonitorWriter{b}
+}
+
+// openBackends opens the backends that are listed in spec and returns the
+// backends that were opened along with an error describing the backends that
+// could not be opened.
+func openBackends(spec string) (Backend, error) {
+	var opened multiBackend
+	var errs []error
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if "" == s {
+			continue
+		}
+
+		name, arg := s, ""
+		if i := strings.Index(s, ":"); 0 <= i {
+			name, arg = s[:i], s[i+1:]
+		}
+
+		backendsMu.RLock()
+		factory, ok := backends[name]
+		backendsMu.RUnlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("procmon: unknown backend %q", name))
+			continue
+		}
+
+		b, err := factory(arg)
+		if nil != err {
+			errs = append(errs, fmt.Errorf("backend %q: %w", name, err))
+			continue
+		}
+
+		opened = append(opened, b)
+	}
+
+	err := errors.Join(errs...)
+	switch len(opened) {
+	case 0:
+		if nil == err {
+			err = errors.New("procmon: no backends were specified")
+		}
+
+		return nil, err
+	case 1:
+		return opened[0], err
+	}
+
+	return opened, err
+}
+
+// multiBackend writes each debug message to several backends. A failure to
+// write to one backend does not prevent the message from being written to
+// the others.
+type multiBackend []Backend
+
+func (m multiBackend) Write(message string) error {
+	var errs []error
+	for _, b := range m {
+		if err := b.Write(message); nil != err {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m multiBackend) Close() error {
+	var errs []error
+	for _, b := range m {
+		if err := b.Close(); nil != err {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// nullBackend discards debug messages.
+type nullBackend struct{}
+
+func openNullBackend(arg string) (Backend, error) {
+	return nullBackend{}, nil
+}
+
+func (nullBackend) Write(message string) error {
+	return nil
+}
+
+func (nullBackend) Close() error {
+	return nil
+}
+
+// debuggerBackend writes debug messages to the debugger console using the
+// go-debugger library. On Microsoft Windows, the messages can be viewed
+// using DebugView or an attached debugger.
+type debuggerBackend struct{}
+
+func openDebuggerBackend(arg string) (Backend, error) {
+	return debuggerBackend{}, nil
+}
+
+func (debuggerBackend) Write(message string) error {
+	_, err := io.WriteString(debugger.Console, message)
+	return err
+}
+
+func (debuggerBackend) Close() error {
+	return nil
+}
+
+// streamBackend writes each debug message to a stream on its own line.
+type streamBackend struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	closed bool
+}
+
+func openStderrBackend(arg string) (Backend, error) {
+	return &streamBackend{w: os.Stderr}, nil
+}
+
+func openFileBackend(arg string) (Backend, error) {
+	if "" == arg {
+		return nil, errors.New("a file path is required")
+	}
+
+	f, err := os.OpenFile(arg, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if nil != err {
+		return nil, err
+	}
+
+	return &streamBackend{w: f, closer: f}, nil
+}
+
+func (b *streamBackend) Write(message string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	_, err := io.WriteString(b.w, message+"\n")
+	return err
+}
+
+func (b *streamBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	b.closed = true
+	if nil == b.closer {
+		return nil
+	}
+
+	return b.closer.Close()
+}
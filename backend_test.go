@@ -0,0 +1,159 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failingBackend is a Backend that fails every write.
+type failingBackend struct{}
+
+func (failingBackend) Write(message string) error {
+	return errors.New("write failed")
+}
+
+func (failingBackend) Close() error {
+	return nil
+}
+
+// backendFunc is a Backend that calls a function to write each message.
+type backendFunc func(message string) error
+
+func (f backendFunc) Write(message string) error {
+	return f(message)
+}
+
+func (f backendFunc) Close() error {
+	return nil
+}
+
+var _ = Describe("Backend registry", func() {
+	var mock *mockProcessMonitor
+
+	BeforeEach(func() {
+		mock = &mockProcessMonitor{}
+		RegisterBackend("mock", func(arg string) (Backend, error) {
+			mock.message = arg
+			return mock, nil
+		})
+		RegisterBackend("failing", func(arg string) (Backend, error) {
+			return failingBackend{}, nil
+		})
+	})
+
+	AfterEach(func() {
+		RegisterBackend("mock", nil)
+		RegisterBackend("failing", nil)
+	})
+
+	Describe("Backends", func() {
+		It("lists the registered backends", func() {
+			Expect(Backends()).To(ContainElement("mock"))
+			Expect(Backends()).To(ContainElement("procmon"))
+			Expect(Backends()).To(ContainElement("stderr"))
+		})
+	})
+
+	Describe("OpenBackend", func() {
+		It("passes the argument to the factory", func() {
+			_, err := OpenBackend("mock:some argument")
+			Expect(err).To(BeNil())
+			Expect(mock.message).To(Equal("some argument"))
+		})
+
+		It("returns an error for unknown backends", func() {
+			_, err := OpenBackend("mock,unknown")
+			Expect(err).To(MatchError(ContainSubstring(`unknown backend "unknown"`)))
+		})
+
+		It("returns an error when no backends are specified", func() {
+			_, err := OpenBackend(" , ")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("writes to every backend that is listed", func() {
+			b, err := OpenBackend("failing,mock")
+			Expect(err).To(BeNil())
+
+			err = b.Write("Hello, World!")
+			Expect(err).To(MatchError("write failed"))
+			Expect(mock.messages).To(Equal([]string{"Hello, World!"}))
+		})
+	})
+
+	Describe("RegisterBackend", func() {
+		It("opens a backend that is named by PROCMON_BACKEND after it is registered", func() {
+			defer os.Unsetenv(BackendEnvironmentVariable)
+			os.Setenv(BackendEnvironmentVariable, "late:arg")
+			_, err := Open(Options{})
+			Expect(err).To(MatchError(ContainSubstring(`unknown backend "late"`)))
+			defer Close()
+
+			late := &mockProcessMonitor{}
+			RegisterBackend("late", func(arg string) (Backend, error) {
+				late.message = arg
+				return late, nil
+			})
+			defer RegisterBackend("late", nil)
+
+			Expect(late.message).To(Equal("arg"))
+			ProcessMonitor.WriteString("Hello, World!")
+			Expect(late.messages).To(Equal([]string{"Hello, World!"}))
+		})
+	})
+
+	Describe("file backend", func() {
+		It("appends each message to the file on its own line", func() {
+			dir, err := os.MkdirTemp("", "procmon")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "debug.log")
+			b, err := OpenBackend("file:" + path)
+			Expect(err).To(BeNil())
+
+			w := NewWriter(b)
+			io.WriteString(w, "one")
+			io.WriteString(w, "two")
+			Expect(b.Close()).To(Succeed())
+
+			contents, err := os.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(Equal("one\ntwo\n"))
+		})
+
+		It("requires a path", func() {
+			_, err := OpenBackend("file")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
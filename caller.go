@@ -0,0 +1,87 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"runtime"
+	"strings"
+)
+
+// packagePath is the import path of the procmon package. Stack frames in
+// the procmon package and its subpackages are skipped when looking for the
+// code that wrote a debug message.
+var packagePath = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")]
+}()
+
+// forwardingPackages lists the standard library packages that forward
+// output to a Writer. Stack frames in these packages are skipped when
+// looking for the code that wrote a debug message.
+var forwardingPackages = []string{
+	"bufio.",
+	"fmt.",
+	"io.",
+	"log.",
+	"log/slog.",
+	"runtime.",
+	"sync.",
+}
+
+// callerFrame returns the stack frame of the code that wrote a debug
+// message. Frames in the procmon package, its subpackages, and the standard
+// library packages that forward output to a Writer are skipped.
+func callerFrame() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isForwardingFrame(frame) {
+			return frame, true
+		}
+
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+func isForwardingFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, packagePath+".") ||
+		strings.HasPrefix(frame.Function, packagePath+"/") {
+		return true
+	}
+
+	for _, prefix := range forwardingPackages {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon_test
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// These specs are in the procmon_test package because frames in the procmon
+// package are skipped when looking for the code that wrote a debug message.
+var _ = Describe("Caller", func() {
+	var recorder *procmontest.Recorder
+	var w procmon.Writer
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		w = procmon.NewWriter(recorder)
+	})
+
+	It("is reported by a Decorator", func() {
+		d, err := procmon.NewDecorator(w, procmon.DecoratorOptions{
+			Format: "{file}|{line}|{func}|{caller}",
+		})
+		Expect(err).To(BeNil())
+
+		_, function, line := callerLine()
+		fmt.Fprintf(d, "formatted %d", 1)
+		Expect(recorder.Texts()).To(Equal([]string{fmt.Sprintf(
+			"caller_test.go|%d|%s|caller_test.go:%d formatted 1",
+			line+1, path.Base(function), line+1)}))
+	})
+
+	It("skips the leveled loggers", func() {
+		d, _ := procmon.NewDecorator(w, procmon.DecoratorOptions{
+			Format: "{caller} {message}",
+		})
+
+		_, _, line := callerLine()
+		procmon.New(d).Warnf("careful")
+		Expect(recorder.Texts()).To(Equal([]string{
+			fmt.Sprintf("caller_test.go:%d WARN: careful", line+1),
+		}))
+	})
+
+	It("separates the call sites of a RateLimiter", func() {
+		limiter := procmon.NewRateLimiter(w, procmon.RateLimitOptions{First: 1})
+		_, _, line := callerLine()
+		io.WriteString(limiter, "one")
+		io.WriteString(limiter, "two")
+		for i := 0; i < 3; i++ {
+			io.WriteString(limiter, "three")
+		}
+
+		Expect(limiter.Flush()).To(Succeed())
+		Expect(recorder.Texts()).To(HaveLen(4))
+		Expect(recorder.Texts()[:3]).To(Equal([]string{"one", "two", "three"}))
+		Expect(recorder.Texts()[3]).To(MatchRegexp(fmt.Sprintf(
+			`^procmon: suppressed 2 messages from \S*caller_test\.go:%d$`, line+4)))
+	})
+
+	It("separates the call sites of a Sampler", func() {
+		sampler := procmon.NewSampler(w, procmon.SamplerOptions{Rate: 0})
+		_, _, line := callerLine()
+		io.WriteString(sampler, "one")
+		for i := 0; i < 2; i++ {
+			io.WriteString(sampler, "two")
+		}
+
+		Expect(sampler.Flush()).To(Succeed())
+		Expect(recorder.Texts()).To(ConsistOf(
+			MatchRegexp(fmt.Sprintf(
+				`^procmon: sampled out 1 messages from \S*caller_test\.go:%d$`, line+1)),
+			MatchRegexp(fmt.Sprintf(
+				`^procmon: sampled out 2 messages from \S*caller_test\.go:%d$`, line+3)),
+		))
+	})
+})
+
+// callerLine returns the location of the code that called it.
+func callerLine() (file string, function string, line int) {
+	pc, file, line, _ := runtime.Caller(1)
+	return file, runtime.FuncForPC(pc).Name(), line
+}
@@ -0,0 +1,168 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// components maps component names to the settings that are shared by the
+// named Loggers with that name.
+var components = struct {
+	sync.Mutex
+	settings map[string]*loggerSettings
+}{settings: make(map[string]*loggerSettings)}
+
+// ComponentInfo describes a component that has been named by calling Named.
+type ComponentInfo struct {
+	// Name is the full component name, such as "storage.cache".
+	Name string
+
+	// Enabled is true if debug messages from the component are written. A
+	// component is turned off if it or any of its parent components is
+	// turned off.
+	Enabled bool
+
+	// Threshold is the minimum level of the debug messages that are written
+	// by the component.
+	Threshold Level
+
+	// HasThreshold is true if the component has its own threshold instead
+	// of using the threshold of its parent component or the global
+	// threshold.
+	HasThreshold bool
+}
+
+// Named returns a Logger that writes to ProcessMonitor and adds the
+// component name to the beginning of every debug message. Names containing
+// dots, such as "storage.cache", create a Logger for the nested component.
+// Loggers with the same component name share their threshold and their
+// enabled setting, so a component can be turned off or on at runtime by
+// calling Named with its name.
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// Named returns a Logger for a child component of l. The component name of
+// the child is l's component name followed by a dot and name. The child
+// writes to the same Writer as l and uses l's threshold and severity prefix
+// unless it has its own.
+func (l *Logger) Named(name string) *Logger {
+	child := l
+	for _, part := range strings.Split(name, ".") {
+		if "" == part {
+			continue
+		}
+
+		full := part
+		if "" != child.component {
+			full = child.component + "." + part
+		}
+
+		child = &Logger{
+			out:       l.out,
+			component: full,
+			parent:    child,
+			shared:    componentSettings(full),
+		}
+	}
+
+	return child
+}
+
+// Components returns the components that have been named, sorted by name.
+// The threshold of a component without its own threshold is inherited from
+// its parent components or the global threshold.
+func Components() []ComponentInfo {
+	components.Lock()
+	defer components.Unlock()
+
+	names := make([]string, 0, len(components.settings))
+	for name := range components.settings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	infos := make([]ComponentInfo, 0, len(names))
+	for _, name := range names {
+		s := components.settings[name]
+		info := ComponentInfo{
+			Name:         name,
+			Enabled:      true,
+			Threshold:    Threshold(),
+			HasThreshold: s.hasThreshold.Load(),
+		}
+
+		// Walk from the component to its root to find the closest threshold
+		// and whether any ancestor is turned off.
+		found := false
+		for ancestor := name; "" != ancestor; ancestor = parentComponent(ancestor) {
+			a, ok := components.settings[ancestor]
+			if !ok {
+				continue
+			}
+
+			if a.disabled.Load() {
+				info.Enabled = false
+			}
+
+			if !found && a.hasThreshold.Load() {
+				info.Threshold = Level(a.threshold.Load())
+				found = true
+			}
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// componentSettings returns the settings for a component name, creating
+// them if the component has not been named before.
+func componentSettings(name string) *loggerSettings {
+	components.Lock()
+	defer components.Unlock()
+
+	s, ok := components.settings[name]
+	if !ok {
+		s = &loggerSettings{}
+		components.settings[name] = s
+	}
+
+	return s
+}
+
+// parentComponent returns the name of the parent of a component, or an
+// empty string if the component does not have a parent.
+func parentComponent(name string) string {
+	if i := strings.LastIndex(name, "."); 0 <= i {
+		return name[:i]
+	}
+
+	return ""
+}
@@ -0,0 +1,120 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Named", func() {
+	var recorder *entryRecorder
+	var logger *Logger
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+		logger = New(recorder)
+	})
+
+	AfterEach(func() {
+		for _, name := range []string{"storage", "storage.cache", "network"} {
+			l := logger.Named(name)
+			l.SetEnabled(true)
+			l.ResetThreshold()
+		}
+	})
+
+	It("adds the component name to every message", func() {
+		storage := logger.Named("storage")
+		io.WriteString(storage, "opened")
+		storage.Warnf("slow")
+		Expect(recorder.Messages()).To(Equal([]string{
+			"storage: opened",
+			"storage: WARN: slow",
+		}))
+		Expect(recorder.Entries()[0].Component).To(Equal("storage"))
+	})
+
+	It("nests the names of child components", func() {
+		cache := logger.Named("storage").Named("cache")
+		Expect(cache.Component()).To(Equal("storage.cache"))
+		io.WriteString(cache, "miss")
+		Expect(recorder.Messages()).To(Equal([]string{"storage.cache: miss"}))
+	})
+
+	It("creates nested components from dotted names", func() {
+		Expect(logger.Named("storage.cache").Component()).
+			To(Equal("storage.cache"))
+	})
+
+	It("shares settings between loggers with the same name", func() {
+		logger.Named("network").SetEnabled(false)
+		io.WriteString(logger.Named("network"), "connected")
+		Expect(recorder.Messages()).To(BeEmpty())
+	})
+
+	It("turns off child components with their parent", func() {
+		logger.Named("storage").SetEnabled(false)
+		cache := logger.Named("storage.cache")
+		io.WriteString(cache, "miss")
+		cache.Errorf("failed")
+		Expect(recorder.Messages()).To(BeEmpty())
+		Expect(cache.Enabled(LevelError)).To(BeFalse())
+	})
+
+	It("inherits the threshold of the parent component", func() {
+		logger.Named("storage").SetThreshold(LevelError)
+		cache := logger.Named("storage.cache")
+		cache.Warnf("ignored")
+		Expect(recorder.Messages()).To(BeEmpty())
+
+		cache.SetThreshold(LevelDebug)
+		cache.Warnf("written")
+		Expect(recorder.Messages()).To(HaveLen(1))
+	})
+
+	Describe("Components", func() {
+		It("lists the named components and their settings", func() {
+			logger.Named("storage.cache")
+			logger.Named("storage").SetEnabled(false)
+			logger.Named("storage").SetThreshold(LevelWarn)
+
+			var cache ComponentInfo
+			for _, c := range Components() {
+				if "storage.cache" == c.Name {
+					cache = c
+				}
+			}
+
+			Expect(cache).To(Equal(ComponentInfo{
+				Name:      "storage.cache",
+				Enabled:   false,
+				Threshold: LevelWarn,
+			}))
+		})
+	})
+})
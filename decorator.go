@@ -0,0 +1,258 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mfcollins3/go-procmon/internal/goid"
+)
+
+// DefaultDecoratorFormat is the template that is used by a Decorator when
+// DecoratorOptions.Format is not set.
+const DefaultDecoratorFormat = "{time} [g{goroutine} t{thread}] {caller}: {message}"
+
+// DefaultTimeLayout is the layout of the {time} field when the template does
+// not specify a layout.
+const DefaultTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// DecoratorOptions configures a Decorator.
+type DecoratorOptions struct {
+	// Format is the template that describes how debug messages are
+	// decorated. Fields in the template are written in braces and are
+	// replaced with information about the debug message:
+	//
+	//	{time}         the time that the message was written
+	//	{time:layout}  the time formatted using a time.Format layout
+	//	{goroutine}    the ID of the goroutine that wrote the message
+	//	{thread}       the ID of the operating system thread
+	//	{file}         the name of the source file that wrote the message
+	//	{line}         the line number in the source file
+	//	{caller}       the file name and line number, such as main.go:42
+	//	{func}         the name of the function that wrote the message
+	//	{component}    the component name
+	//	{level}        the level of the message
+	//	{message}      the text of the debug message
+	//
+	// Use {{ to write a literal brace. If the template does not contain
+	// {message}, the message is appended to the end of the template. If
+	// Format is empty, DefaultDecoratorFormat is used.
+	Format string
+
+	// Component is the component name that is used for debug messages that
+	// were not written by a named Logger.
+	Component string
+}
+
+// Decorator adds information such as a timestamp, the goroutine and thread
+// IDs, and the location of the calling code to each debug message that is
+// written to it, and then writes the decorated message to the underlying
+// Writer.
+//
+// The goroutine, thread, and caller fields describe the code that is running
+// when the message is written to the Decorator, so a Decorator should be
+// placed in front of writers such as AsyncWriter that write messages on
+// another goroutine.
+type Decorator struct {
+	w         Writer
+	component string
+	segments  []segment
+	caller    bool
+}
+
+// segment is a piece of a decorator template. A segment is either literal
+// text or a field that is replaced with information about the message.
+type segment struct {
+	text   string
+	field  string
+	layout string
+}
+
+// NewDecorator returns a Decorator that decorates debug messages and writes
+// them to w. An error is returned if the template contains an unknown
+// field.
+func NewDecorator(w Writer, opts DecoratorOptions) (*Decorator, error) {
+	format := opts.Format
+	if "" == format {
+		format = DefaultDecoratorFormat
+	}
+
+	segments, err := parseDecoratorFormat(format)
+	if nil != err {
+		return nil, err
+	}
+
+	d := &Decorator{w: w, component: opts.Component, segments: segments}
+	for _, s := range segments {
+		switch s.field {
+		case "file", "line", "caller", "func":
+			d.caller = true
+		}
+	}
+
+	return d, nil
+}
+
+// Write decorates p and writes it as a debug message.
+func (d *Decorator) Write(p []byte) (n int, err error) {
+	if err = d.WriteEntry(newEntry(string(p))); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString decorates s and writes it as a debug message.
+func (d *Decorator) WriteString(s string) (n int, err error) {
+	if err = d.WriteEntry(newEntry(s)); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// WriteEntry decorates the message in e and writes it to the underlying
+// Writer.
+func (d *Decorator) WriteEntry(e *Entry) error {
+	decorated := *e
+	decorated.Message = d.decorate(e)
+	return WriteEntry(d.w, &decorated)
+}
+
+func (d *Decorator) discards() bool {
+	return discards(d.w)
+}
+
+func (d *Decorator) decorate(e *Entry) string {
+	var file, function string
+	var line int
+	if d.caller {
+		if frame, ok := callerFrame(); ok {
+			file, line = path.Base(frame.File), frame.Line
+			function = frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+		}
+	}
+
+	component := e.Component
+	if "" == component {
+		component = d.component
+	}
+
+	var b strings.Builder
+	for _, s := range d.segments {
+		switch s.field {
+		case "":
+			b.WriteString(s.text)
+		case "time":
+			b.WriteString(e.Time.Format(s.layout))
+		case "goroutine":
+			b.WriteString(strconv.FormatUint(goid.Current(), 10))
+		case "thread":
+			b.WriteString(strconv.FormatUint(threadID(), 10))
+		case "file":
+			b.WriteString(file)
+		case "line":
+			b.WriteString(strconv.Itoa(line))
+		case "caller":
+			b.WriteString(file + ":" + strconv.Itoa(line))
+		case "func":
+			b.WriteString(function)
+		case "component":
+			b.WriteString(component)
+		case "level":
+			b.WriteString(e.Level.String())
+		case "message":
+			b.WriteString(e.Message)
+		}
+	}
+
+	return b.String()
+}
+
+// parseDecoratorFormat splits a decorator template into segments.
+func parseDecoratorFormat(format string) ([]segment, error) {
+	var segments []segment
+	var text strings.Builder
+	hasMessage := false
+	for 0 < len(format) {
+		i := strings.IndexByte(format, '{')
+		if 0 > i {
+			text.WriteString(format)
+			break
+		}
+
+		text.WriteString(format[:i])
+		format = format[i:]
+		if strings.HasPrefix(format, "{{") {
+			text.WriteByte('{')
+			format = format[2:]
+			continue
+		}
+
+		end := strings.IndexByte(format, '}')
+		if 0 > end {
+			return nil, fmt.Errorf("procmon: unterminated field in decorator format %q", format)
+		}
+
+		name, layout := format[1:end], ""
+		if j := strings.IndexByte(name, ':'); 0 <= j {
+			name, layout = name[:j], name[j+1:]
+		}
+
+		switch name {
+		case "time":
+			if "" == layout {
+				layout = DefaultTimeLayout
+			}
+		case "goroutine", "thread", "file", "line", "caller", "func",
+			"component", "level":
+		case "message":
+			hasMessage = true
+		default:
+			return nil, fmt.Errorf("procmon: unknown field {%s} in decorator format", name)
+		}
+
+		if 0 < text.Len() {
+			segments = append(segments, segment{text: text.String()})
+			text.Reset()
+		}
+
+		segments = append(segments, segment{field: name, layout: layout})
+		format = format[end+1:]
+	}
+
+	if 0 < text.Len() {
+		segments = append(segments, segment{text: text.String()})
+	}
+
+	if !hasMessage {
+		segments = append(segments, segment{text: " "}, segment{field: "message"})
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,105 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mfcollins3/go-procmon/internal/goid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decorator", func() {
+	var recorder *entryRecorder
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+	})
+
+	decorate := func(opts DecoratorOptions, write func(w Writer)) string {
+		d, err := NewDecorator(recorder, opts)
+		Expect(err).To(BeNil())
+		write(d)
+		Expect(recorder.Messages()).To(HaveLen(1))
+		return recorder.Messages()[0]
+	}
+
+	It("uses the default format", func() {
+		message := decorate(DecoratorOptions{}, func(w Writer) {
+			io.WriteString(w, "Hello, World!")
+		})
+		Expect(message).To(MatchRegexp(
+			`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S* \[g\d+ t\d+\] \S+\.go:\d+: Hello, World!$`))
+	})
+
+	It("formats the time using the layout", func() {
+		e := &Entry{
+			Time:    time.Date(2015, 10, 21, 16, 29, 0, 0, time.UTC),
+			Message: "Hello",
+		}
+		message := decorate(DecoratorOptions{Format: "{time:15:04} {message}"},
+			func(w Writer) {
+				WriteEntry(w, e)
+			})
+		Expect(message).To(Equal("16:29 Hello"))
+	})
+
+	It("reports the goroutine that wrote the message", func() {
+		message := decorate(DecoratorOptions{Format: "{goroutine}"},
+			func(w Writer) {
+				io.WriteString(w, "Hello")
+			})
+		Expect(message).To(Equal(strconv.FormatUint(goid.Current(), 10) + " Hello"))
+	})
+
+	It("uses the component of the entry or the default component", func() {
+		d, _ := NewDecorator(recorder, DecoratorOptions{
+			Format:    "{component}: {level}: {message}",
+			Component: "app",
+		})
+		io.WriteString(d, "one")
+		WriteEntry(d, &Entry{Component: "storage", Level: LevelWarn, Message: "two"})
+		Expect(recorder.Messages()).To(Equal([]string{
+			"app: INFO: one",
+			"storage: WARN: two",
+		}))
+	})
+
+	It("writes literal braces", func() {
+		message := decorate(DecoratorOptions{Format: "{{x} {message}"},
+			func(w Writer) {
+				io.WriteString(w, "Hello")
+			})
+		Expect(message).To(Equal("{x} Hello"))
+	})
+
+	It("returns an error for unknown fields", func() {
+		_, err := NewDecorator(recorder, DecoratorOptions{Format: "{pid}"})
+		Expect(err).To(MatchError(ContainSubstring("{pid}")))
+	})
+})
@@ -0,0 +1,188 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Deduplicator suppresses debug messages that repeat the previous message.
+// The first copy of a message is written immediately. Copies that arrive
+// within the window after the previous copy are counted instead of being
+// written, and a summary such as "last message repeated 1832 times in 4.2s"
+// is written when a different message arrives, when the window ends without
+// another copy, or when Flush or Close is called.
+//
+// Deduplicator is safe for concurrent use.
+type Deduplicator struct {
+	w      Writer
+	window time.Duration
+
+	mu      sync.Mutex
+	last    *Entry
+	first   time.Time
+	latest  time.Time
+	repeats int
+	timer   *time.Timer
+	gen     int
+	closed  bool
+}
+
+// NewDeduplicator returns a Deduplicator that writes to w and suppresses
+// copies of a message that arrive within window of the previous copy.
+func NewDeduplicator(w Writer, window time.Duration) *Deduplicator {
+	return &Deduplicator{w: w, window: window}
+}
+
+// Write writes p as a debug message unless it repeats the previous message.
+func (d *Deduplicator) Write(p []byte) (n int, err error) {
+	if err = d.WriteEntry(newEntry(string(p))); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString writes s as a debug message unless it repeats the previous
+// message.
+func (d *Deduplicator) WriteString(s string) (n int, err error) {
+	if err = d.WriteEntry(newEntry(s)); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// WriteEntry writes e unless it repeats the previous message. Messages
+// repeat if they have the same text, level, and component.
+func (d *Deduplicator) WriteEntry(e *Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	now := time.Now()
+	if nil != d.last && d.isRepeat(e) && now.Sub(d.latest) <= d.window {
+		d.repeats++
+		d.latest = now
+		d.resetTimer()
+		return nil
+	}
+
+	err := d.flush()
+	c := *e
+	d.last = &c
+	d.first, d.latest = now, now
+	if werr := WriteEntry(d.w, e); nil == err {
+		err = werr
+	}
+
+	return err
+}
+
+// Flush writes the summary for the repeats of the previous message, if
+// there are any.
+func (d *Deduplicator) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.flush()
+}
+
+// Close writes the summary for the repeats of the previous message and
+// stops the window timer. Writes to the Deduplicator after Close return
+// ErrClosed. Close does not close the underlying Writer.
+func (d *Deduplicator) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+
+	d.closed = true
+	return d.flush()
+}
+
+func (d *Deduplicator) discards() bool {
+	return discards(d.w)
+}
+
+// isRepeat returns true if e is a copy of the previous message. The caller
+// must hold the lock.
+func (d *Deduplicator) isRepeat(e *Entry) bool {
+	return e.Message == d.last.Message &&
+		e.Level == d.last.Level &&
+		e.Component == d.last.Component
+}
+
+// flush writes the summary for the repeats of the previous message and
+// forgets the previous message. The caller must hold the lock.
+func (d *Deduplicator) flush() error {
+	if nil != d.timer {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	d.gen++
+	last, repeats := d.last, d.repeats
+	d.last, d.repeats = nil, 0
+	if 0 == repeats {
+		return nil
+	}
+
+	return WriteEntry(d.w, &Entry{
+		Time:      d.latest,
+		Level:     last.Level,
+		Component: last.Component,
+		Message: fmt.Sprintf("last message repeated %d times in %v",
+			repeats, d.latest.Sub(d.first)),
+	})
+}
+
+// resetTimer schedules the summary to be written when the window ends
+// without another copy of the message. The caller must hold the lock.
+func (d *Deduplicator) resetTimer() {
+	if nil != d.timer {
+		d.timer.Stop()
+	}
+
+	// The generation keeps a timer that fired while the lock was held from
+	// flushing a later sequence of repeats.
+	d.gen++
+	gen := d.gen
+	d.timer = time.AfterFunc(d.window, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if gen == d.gen {
+			d.flush()
+		}
+	})
+}
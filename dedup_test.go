@@ -0,0 +1,99 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deduplicator", func() {
+	var recorder *entryRecorder
+	var dedup *Deduplicator
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+		dedup = NewDeduplicator(recorder, time.Minute)
+	})
+
+	AfterEach(func() {
+		dedup.Close()
+	})
+
+	It("writes the first copy of a message", func() {
+		io.WriteString(dedup, "retrying")
+		Expect(recorder.Messages()).To(Equal([]string{"retrying"}))
+	})
+
+	It("writes a summary when a different message arrives", func() {
+		for i := 0; i < 4; i++ {
+			io.WriteString(dedup, "retrying")
+		}
+
+		io.WriteString(dedup, "connected")
+		messages := recorder.Messages()
+		Expect(messages).To(HaveLen(3))
+		Expect(messages[0]).To(Equal("retrying"))
+		Expect(messages[1]).To(MatchRegexp(`^last message repeated 3 times in \S+$`))
+		Expect(messages[2]).To(Equal("connected"))
+	})
+
+	It("does not write a summary for messages that do not repeat", func() {
+		io.WriteString(dedup, "one")
+		io.WriteString(dedup, "two")
+		Expect(recorder.Messages()).To(Equal([]string{"one", "two"}))
+	})
+
+	It("treats messages from different components as different", func() {
+		logger := New(dedup)
+		logger.Named("storage").Print("retrying")
+		logger.Named("network").Print("retrying")
+		Expect(recorder.Messages()).To(HaveLen(2))
+	})
+
+	It("writes the summary when the window ends", func() {
+		dedup = NewDeduplicator(recorder, 20*time.Millisecond)
+		io.WriteString(dedup, "retrying")
+		io.WriteString(dedup, "retrying")
+		Eventually(recorder.Messages).Should(HaveLen(2))
+		Expect(recorder.Messages()[1]).To(HavePrefix("last message repeated 1 times"))
+
+		io.WriteString(dedup, "retrying")
+		Expect(recorder.Messages()).To(HaveLen(3))
+	})
+
+	It("writes the summary when closed", func() {
+		io.WriteString(dedup, "retrying")
+		io.WriteString(dedup, "retrying")
+		Expect(dedup.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(HaveLen(2))
+
+		_, err := io.WriteString(dedup, "retrying")
+		Expect(err).To(Equal(ErrClosed))
+	})
+})
@@ -0,0 +1,134 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF16Backend is implemented by backends that accept debug messages that
+// have already been encoded as UTF-16. Writers pass UTF-16 messages to these
+// backends using buffers from a pool, which avoids allocating memory for
+// each debug message.
+type UTF16Backend interface {
+	Backend
+
+	// WriteUTF16 writes a single debug message. The message is terminated
+	// by a NUL code unit, which is included in message. The message is only
+	// valid until WriteUTF16 returns.
+	WriteUTF16(message []uint16) error
+}
+
+// AppendUTF16 appends the UTF-16 encoding of the UTF-8 text in p to dst and
+// returns the extended buffer. Invalid UTF-8 bytes are encoded as U+FFFD.
+func AppendUTF16(dst []uint16, p []byte) []uint16 {
+	for i := 0; i < len(p); {
+		if c := p[i]; c < utf8.RuneSelf {
+			dst = append(dst, uint16(c))
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(p[i:])
+		dst = appendRune(dst, r)
+		i += size
+	}
+
+	return dst
+}
+
+// AppendUTF16String appends the UTF-16 encoding of s to dst and returns the
+// extended buffer. Invalid UTF-8 bytes are encoded as U+FFFD.
+func AppendUTF16String(dst []uint16, s string) []uint16 {
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			dst = append(dst, uint16(c))
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		dst = appendRune(dst, r)
+		i += size
+	}
+
+	return dst
+}
+
+func appendRune(dst []uint16, r rune) []uint16 {
+	if r >= 0x10000 {
+		r1, r2 := utf16.EncodeRune(r)
+		return append(dst, uint16(r1), uint16(r2))
+	}
+
+	return append(dst, uint16(r))
+}
+
+// utf16LenBytes returns the number of UTF-16 code units that are needed to
+// encode p. Invalid UTF-8 bytes are counted as U+FFFD.
+func utf16LenBytes(p []byte) int {
+	n := 0
+	for i := 0; i < len(p); {
+		if p[i] < utf8.RuneSelf {
+			n++
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(p[i:])
+		n += runeLen(r)
+		i += size
+	}
+
+	return n
+}
+
+// maxPooledBuffer is the largest buffer that is returned to the pool. Larger
+// buffers are left for the garbage collector so that one long message does
+// not pin a large buffer in memory.
+const maxPooledBuffer = 4 * MaxMessageLength
+
+var utf16Buffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]uint16, 0, MaxMessageLength+1)
+		return &b
+	},
+}
+
+// getUTF16Buffer returns an empty buffer from the pool.
+func getUTF16Buffer() *[]uint16 {
+	b := utf16Buffers.Get().(*[]uint16)
+	*b = (*b)[:0]
+	return b
+}
+
+// putUTF16Buffer returns a buffer to the pool.
+func putUTF16Buffer(b *[]uint16) {
+	if cap(*b) <= maxPooledBuffer {
+		utf16Buffers.Put(b)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// utf16Recorder is a UTF16Backend that keeps the last message that it
+// received without allocating memory.
+type utf16Recorder struct {
+	last   []uint16
+	writes int
+}
+
+func newUTF16Recorder() *utf16Recorder {
+	return &utf16Recorder{last: make([]uint16, 0, 4*MaxMessageLength)}
+}
+
+func (r *utf16Recorder) Write(message string) error {
+	r.last = append(AppendUTF16String(r.last[:0], message), 0)
+	r.writes++
+	return nil
+}
+
+func (r *utf16Recorder) WriteUTF16(message []uint16) error {
+	r.last = append(r.last[:0], message...)
+	r.writes++
+	return nil
+}
+
+func (r *utf16Recorder) Close() error {
+	return nil
+}
+
+// String decodes the last message without the terminating NUL.
+func (r *utf16Recorder) String() string {
+	return string(utf16.Decode(r.last[:len(r.last)-1]))
+}
+
+var _ = Describe("UTF-16 encoding", func() {
+	It("encodes ASCII text", func() {
+		Expect(AppendUTF16(nil, []byte("hello"))).To(Equal(utf16.Encode([]rune("hello"))))
+	})
+
+	It("encodes characters outside the BMP as surrogate pairs", func() {
+		text := "a\U0001F600b"
+		Expect(AppendUTF16(nil, []byte(text))).To(Equal([]uint16{'a', 0xD83D, 0xDE00, 'b'}))
+		Expect(AppendUTF16String(nil, text)).To(Equal([]uint16{'a', 0xD83D, 0xDE00, 'b'}))
+		Expect(utf16LenBytes([]byte(text))).To(Equal(4))
+	})
+
+	It("replaces each invalid UTF-8 byte with U+FFFD", func() {
+		text := "a\xffb\xe2\x82"
+		expected := []uint16{'a', 0xFFFD, 'b', 0xFFFD, 0xFFFD}
+		Expect(AppendUTF16(nil, []byte(text))).To(Equal(expected))
+		Expect(AppendUTF16String(nil, text)).To(Equal(expected))
+		Expect(utf16LenBytes([]byte(text))).To(Equal(len(expected)))
+	})
+
+	It("appends to an existing buffer", func() {
+		Expect(AppendUTF16([]uint16{'x'}, []byte("é"))).To(Equal([]uint16{'x', 0xE9}))
+	})
+
+	It("does not keep oversized buffers in the pool", func() {
+		b := make([]uint16, 0, maxPooledBuffer+1)
+		putUTF16Buffer(&b)
+		Expect(cap(*getUTF16Buffer())).To(BeNumerically("<=", maxPooledBuffer))
+	})
+
+	Context("when writing to a UTF-16 backend", func() {
+		var backend *utf16Recorder
+		var writer Writer
+
+		BeforeEach(func() {
+			backend = newUTF16Recorder()
+			writer = NewWriter(backend)
+		})
+
+		It("writes a NUL-terminated message", func() {
+			n, err := writer.Write([]byte("héllo \U0001F600"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(len("héllo \U0001F600")))
+			Expect(backend.last[len(backend.last)-1]).To(BeZero())
+			Expect(backend.String()).To(Equal("héllo \U0001F600"))
+		})
+
+		It("splits messages that are too long", func() {
+			_, err := writer.WriteString(string(make([]byte, 10)) + longMessage(MaxMessageLength))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend.writes).To(BeNumerically(">", 1))
+		})
+
+		It("does not allocate memory", func() {
+			if raceEnabled {
+				Skip("allocations are not measured with the race detector")
+			}
+
+			p := []byte("the quick brown fox jumps over the lazy dög")
+			Expect(testing.AllocsPerRun(100, func() {
+				writer.Write(p)
+			})).To(BeZero())
+			Expect(testing.AllocsPerRun(100, func() {
+				writer.WriteString("the quick brown fox")
+			})).To(BeZero())
+		})
+
+		It("does not allocate memory when writing through a connection", func() {
+			if raceEnabled {
+				Skip("allocations are not measured with the race detector")
+			}
+
+			w, err := Open(Options{
+				Backend: "utf16",
+				Opener: func(spec string) (Backend, error) {
+					return backend, nil
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer Close()
+
+			p := []byte("the quick brown fox jumps over the lazy dög")
+			Expect(testing.AllocsPerRun(100, func() {
+				w.Write(p)
+			})).To(BeZero())
+			Expect(backend.String()).To(Equal(string(p)))
+		})
+	})
+})
+
+func longMessage(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 'a' + byte(i%26)
+	}
+
+	return string(b)
+}
+
+func BenchmarkAppendUTF16(b *testing.B) {
+	p := []byte("Process Monitor debug message with some ünïcödé text")
+	buf := make([]uint16, 0, MaxMessageLength+1)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		buf = AppendUTF16(buf[:0], p)
+	}
+}
+
+func BenchmarkWriterWrite(b *testing.B) {
+	w := NewWriter(newUTF16Recorder())
+	p := []byte("Process Monitor debug message with some ünïcödé text")
+	b.ReportAllocs()
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		w.Write(p)
+	}
+}
+
+func BenchmarkWriterWriteString(b *testing.B) {
+	w := NewWriter(newUTF16Recorder())
+	s := "Process Monitor debug message with some ünïcödé text"
+	b.ReportAllocs()
+	b.SetBytes(int64(len(s)))
+	for i := 0; i < b.N; i++ {
+		w.WriteString(s)
+	}
+}
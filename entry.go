@@ -0,0 +1,94 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import "time"
+
+// Entry is a debug message along with the information that describes where
+// and when it was written. Writers that implement EntryWriter receive
+// entries from the leveled functions and can use the information, for
+// example to filter debug messages by level or component.
+type Entry struct {
+	// Time is the time that the debug message was written.
+	Time time.Time
+
+	// Level is the severity of the debug message. Messages that are written
+	// without a level use LevelInfo.
+	Level Level
+
+	// Component is the name of the part of the program that wrote the debug
+	// message, or an empty string.
+	Component string
+
+	// TraceID identifies the request or operation that the debug message
+	// belongs to, or is an empty string. A Sampler writes all or none of
+	// the messages with the same TraceID.
+	TraceID string
+
+	// Message is the text of the debug message, including the component
+	// name and the severity prefix if the message was written by a named
+	// Logger or a leveled function.
+	Message string
+}
+
+// EntryWriter is implemented by Writers that accept entries in addition to
+// plain debug messages.
+type EntryWriter interface {
+	Writer
+
+	// WriteEntry writes the debug message that is described by e.
+	WriteEntry(e *Entry) error
+}
+
+// WriteEntry writes e to w. If w implements EntryWriter, e is passed to
+// WriteEntry. Otherwise, the text of the message is written using
+// WriteString.
+func WriteEntry(w Writer, e *Entry) error {
+	if ew, ok := w.(EntryWriter); ok {
+		return ew.WriteEntry(e)
+	}
+
+	_, err := w.WriteString(e.Message)
+	return err
+}
+
+// newEntry returns an Entry for a debug message that was written without a
+// level.
+func newEntry(message string) *Entry {
+	return &Entry{Time: time.Now(), Level: LevelInfo, Message: message}
+}
+
+// discarder is implemented by Writers that can report whether every debug
+// message that is written to them is discarded.
+type discarder interface {
+	discards() bool
+}
+
+// discards returns true if w is known to discard every debug message that is
+// written to it.
+func discards(w Writer) bool {
+	d, ok := w.(discarder)
+	return ok && d.discards()
+}
@@ -0,0 +1,69 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import "errors"
+
+var (
+	// ErrNotAvailable is returned when Process Monitor is not installed or
+	// is not running, or when the program is not running on Microsoft
+	// Windows.
+	ErrNotAvailable = errors.New("procmon: Process Monitor is not available")
+
+	// ErrMessageTooLong is returned when a debug message is longer than the
+	// size limit and the MessageSizer policy is RejectMessage.
+	ErrMessageTooLong = errors.New("procmon: message is too long")
+
+	// ErrClosed is returned when a debug message is written to a writer or
+	// backend that has been closed.
+	ErrClosed = errors.New("procmon: writer is closed")
+)
+
+// DeviceError records an error that was returned by the operating system
+// while opening or writing to the Process Monitor device. Errors that mean
+// that Process Monitor is not running also match ErrNotAvailable when they
+// are tested with errors.Is.
+type DeviceError struct {
+	// Op is the name of the system call that failed.
+	Op string
+
+	// Err is the error that was returned by the operating system.
+	Err error
+}
+
+func (e *DeviceError) Error() string {
+	return "procmon: " + e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error that was returned by the operating system.
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the operating system error means that Process Monitor
+// is not available.
+func (e *DeviceError) Is(target error) bool {
+	return ErrNotAvailable == target && deviceNotAvailable(e.Err)
+}
@@ -0,0 +1,162 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeviceError", func() {
+	It("wraps the operating system error", func() {
+		err := error(&DeviceError{Op: "DeviceIoControl", Err: os.ErrPermission})
+		Expect(err.Error()).To(Equal("procmon: DeviceIoControl: " + os.ErrPermission.Error()))
+		Expect(errors.Is(err, os.ErrPermission)).To(BeTrue())
+
+		var deviceErr *DeviceError
+		Expect(errors.As(errors.Join(errors.New("wrapped"), err), &deviceErr)).To(BeTrue())
+		Expect(deviceErr.Op).To(Equal("DeviceIoControl"))
+	})
+
+	It("does not match ErrNotAvailable for other errors", func() {
+		err := &DeviceError{Op: "DeviceIoControl", Err: os.ErrPermission}
+		Expect(errors.Is(err, ErrNotAvailable)).To(BeFalse())
+	})
+})
+
+var _ = Describe("ErrNotAvailable", func() {
+	It("is returned when the Process Monitor device cannot be opened", func() {
+		if "windows" == runtime.GOOS {
+			Skip("Process Monitor may be running")
+		}
+
+		_, err := OpenBackend("procmon")
+		Expect(errors.Is(err, ErrNotAvailable)).To(BeTrue())
+	})
+
+	It("has the procmon prefix only once", func() {
+		if "windows" == runtime.GOOS {
+			Skip("Process Monitor may be running")
+		}
+
+		_, err := OpenBackend("procmon")
+		Expect(err.Error()).To(HavePrefix(`backend "procmon": procmon: `))
+		Expect(strings.Count(err.Error(), "procmon: ")).To(Equal(1))
+	})
+})
+
+var _ = Describe("ErrMessageTooLong", func() {
+	AfterEach(func() {
+		SetMessageSizer(MessageSizer{})
+	})
+
+	It("is returned when an oversized message is rejected", func() {
+		SetMessageSizer(MessageSizer{Limit: 16, Policy: RejectMessage})
+		backend := &mockProcessMonitor{}
+		n, err := NewWriter(backend).WriteString(strings.Repeat("x", 17))
+		Expect(err).To(MatchError(ErrMessageTooLong))
+		Expect(n).To(BeZero())
+		Expect(backend.messages).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ErrClosed", func() {
+	It("is returned when writing to a closed connection", func() {
+		original := Output()
+		defer func() { SetOutput(original) }()
+
+		opener := &fakeOpener{}
+		w, err := Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Close()).To(Succeed())
+
+		_, err = w.WriteString("late")
+		Expect(err).To(MatchError(ErrClosed))
+		_, err = w.Write([]byte("late"))
+		Expect(err).To(MatchError(ErrClosed))
+	})
+
+	It("is returned when writing to a closed file backend", func() {
+		dir, err := os.MkdirTemp("", "procmon")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		b, err := OpenBackend("file:" + filepath.Join(dir, "procmon.log"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Close()).To(Succeed())
+		Expect(b.Write("late")).To(MatchError(ErrClosed))
+		Expect(b.Close()).To(Succeed())
+	})
+})
+
+var _ = Describe("Partial writes", func() {
+	var writes int
+	var backend Backend
+
+	BeforeEach(func() {
+		writes = 0
+		backend = backendFunc(func(message string) error {
+			writes++
+			if 3 == writes {
+				return errors.New("write failed")
+			}
+
+			return nil
+		})
+		SetMessageSizer(MessageSizer{Limit: 16})
+	})
+
+	AfterEach(func() {
+		SetMessageSizer(MessageSizer{})
+	})
+
+	It("reports the number of bytes that were written", func() {
+		// Each chunk holds 10 bytes of the message after the "(i/n) "
+		// marker.
+		n, err := NewWriter(backend).WriteString(strings.Repeat("x", 50))
+		Expect(err).To(HaveOccurred())
+		Expect(n).To(Equal(20))
+	})
+
+	It("reports nothing written if the message was changed", func() {
+		n, err := NewWriter(backend).WriteString(strings.Repeat("\x00", 50))
+		Expect(err).To(HaveOccurred())
+		Expect(n).To(BeZero())
+	})
+
+	It("works with bufio.Writer", func() {
+		w := bufio.NewWriterSize(NewWriter(backend), 64)
+		w.WriteString(strings.Repeat("x", 50))
+		Expect(w.Flush()).To(HaveOccurred())
+		Expect(w.Buffered()).To(Equal(30))
+	})
+})
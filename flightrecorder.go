@@ -0,0 +1,307 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mfcollins3/go-procmon/internal/goid"
+)
+
+// DefaultFlightRecorderSize is the number of debug messages that a
+// FlightRecorder keeps when no limit is configured.
+const DefaultFlightRecorderSize = 1000
+
+// FlightRecord is a debug message that was kept by a FlightRecorder.
+type FlightRecord struct {
+	// Sequence is the position of the debug message in the sequence of
+	// messages that were written to the FlightRecorder, starting at 1.
+	Sequence uint64
+
+	// Time is the time that the debug message was written.
+	Time time.Time
+
+	// Goroutine is the ID of the goroutine that wrote the debug message.
+	Goroutine uint64
+
+	// Level is the severity of the debug message.
+	Level Level
+
+	// Component is the name of the component that wrote the debug message,
+	// or an empty string.
+	Component string
+
+	// Message is the text of the debug message.
+	Message string
+}
+
+// String formats the record as a single line with the time, sequence
+// number, and goroutine ID of the debug message.
+func (r FlightRecord) String() string {
+	return fmt.Sprintf("%s #%d g%d %s",
+		r.Time.Format(DefaultTimeLayout), r.Sequence, r.Goroutine, r.Message)
+}
+
+// replayed formats the record to be written again after Process Monitor is
+// started. The message is marked as replayed and keeps its original sequence
+// number and time.
+func (r FlightRecord) replayed() string {
+	return fmt.Sprintf("[replayed #%d %s g%d] %s",
+		r.Sequence, r.Time.Format(DefaultTimeLayout), r.Goroutine, r.Message)
+}
+
+// FlightRecorderOptions configures a FlightRecorder.
+type FlightRecorderOptions struct {
+	// MaxMessages is the number of debug messages to keep. If both
+	// MaxMessages and MaxBytes are zero, DefaultFlightRecorderSize is used.
+	MaxMessages int
+
+	// MaxBytes is the total length of the debug messages to keep. If
+	// MaxBytes is zero, the length of the messages is not limited.
+	MaxBytes int
+}
+
+// FlightRecorder keeps the most recent debug messages in memory so that
+// they can be examined after a problem happens, even if Process Monitor
+// was not running. Install a FlightRecorder using SetFlightRecorder to keep
+// every debug message that is written to ProcessMonitor, or use it as a
+// Writer, for example as a Sink of a TeeWriter. FlightRecorder is safe for
+// concurrent use.
+type FlightRecorder struct {
+	maxMessages int
+	maxBytes    int
+	sequence    atomic.Uint64
+
+	mu      sync.Mutex
+	records []FlightRecord
+	start   int
+	bytes   int
+}
+
+// NewFlightRecorder returns an empty FlightRecorder.
+func NewFlightRecorder(opts FlightRecorderOptions) *FlightRecorder {
+	if 0 == opts.MaxMessages && 0 == opts.MaxBytes {
+		opts.MaxMessages = DefaultFlightRecorderSize
+	}
+
+	return &FlightRecorder{
+		maxMessages: opts.MaxMessages,
+		maxBytes:    opts.MaxBytes,
+	}
+}
+
+// Write records a debug message.
+func (fr *FlightRecorder) Write(p []byte) (n int, err error) {
+	fr.WriteEntry(newEntry(string(p)))
+	return len(p), nil
+}
+
+// WriteString records a debug message.
+func (fr *FlightRecorder) WriteString(s string) (n int, err error) {
+	fr.WriteEntry(newEntry(s))
+	return len(s), nil
+}
+
+// WriteEntry records the debug message that is described by e. If a
+// Redactor is installed, it is applied before the message is recorded.
+func (fr *FlightRecorder) WriteEntry(e *Entry) error {
+	fr.record(e)
+	return nil
+}
+
+// record records the debug message that is described by e and returns its
+// sequence number, or zero if the Redactor dropped the message.
+func (fr *FlightRecorder) record(e *Entry) uint64 {
+	message := e.Message
+	if r := CurrentRedactor(); nil != r {
+		var ok bool
+		if message, ok = r.Redact(message); !ok {
+			return 0
+		}
+	}
+
+	// The record is built before the lock is taken so that writers only
+	// hold the lock while the ring is updated.
+	record := FlightRecord{
+		Time:      e.Time,
+		Goroutine: goid.Current(),
+		Level:     e.Level,
+		Component: e.Component,
+		Message:   message,
+	}
+
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	record.Sequence = fr.sequence.Add(1)
+	fr.records = append(fr.records, record)
+	fr.bytes += len(message)
+	for fr.full() {
+		fr.bytes -= len(fr.records[fr.start].Message)
+		fr.records[fr.start] = FlightRecord{}
+		fr.start++
+	}
+
+	// Move the records to the beginning of the slice once most of it is
+	// unused, so that the slice does not grow without limit.
+	if fr.start > len(fr.records)/2 {
+		n := copy(fr.records, fr.records[fr.start:])
+		fr.records = fr.records[:n]
+		fr.start = 0
+	}
+
+	return record.Sequence
+}
+
+// full returns true if the oldest record must be removed to stay within the
+// limits. The newest record is always kept. The caller must hold the lock.
+func (fr *FlightRecorder) full() bool {
+	count := len(fr.records) - fr.start
+	if 1 >= count {
+		return false
+	}
+
+	return (0 < fr.maxMessages && count > fr.maxMessages) ||
+		(0 < fr.maxBytes && fr.bytes > fr.maxBytes)
+}
+
+// Records returns a copy of the debug messages that are kept by the
+// FlightRecorder, from the oldest to the newest.
+func (fr *FlightRecorder) Records() []FlightRecord {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	return append([]FlightRecord(nil), fr.records[fr.start:]...)
+}
+
+// Reset discards the debug messages that are kept by the FlightRecorder.
+// Sequence numbers continue from where they were.
+func (fr *FlightRecorder) Reset() {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	fr.records = nil
+	fr.start = 0
+	fr.bytes = 0
+}
+
+// Dump writes the debug messages that are kept by the FlightRecorder to w,
+// one per line, from the oldest to the newest.
+func (fr *FlightRecorder) Dump(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range fr.Records() {
+		bw.WriteString(r.String())
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// DumpToFile writes the debug messages that are kept by the FlightRecorder
+// to a new file, for example to include in a support bundle. The file is
+// replaced if it already exists.
+func (fr *FlightRecorder) DumpToFile(path string) error {
+	f, err := os.Create(path)
+	if nil != err {
+		return err
+	}
+
+	if err = fr.Dump(f); nil != err {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// DumpOnPanic writes the debug messages that are kept by the FlightRecorder
+// to w if the program panics, and then continues panicking. DumpOnPanic
+// must be deferred directly:
+//
+//	defer recorder.DumpOnPanic(os.Stderr)
+func (fr *FlightRecorder) DumpOnPanic(w io.Writer) {
+	if r := recover(); nil != r {
+		fr.Dump(w)
+		panic(r)
+	}
+}
+
+// DumpOnSignal writes the debug messages that are kept by the
+// FlightRecorder to w each time the process receives one of the signals.
+// The returned function stops listening for the signals.
+//
+//	stop := recorder.DumpOnSignal(os.Stderr, syscall.SIGUSR1)
+//	defer stop()
+func (fr *FlightRecorder) DumpOnSignal(w io.Writer, signals ...os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, signals...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				fr.Dump(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// flightRecorder holds the FlightRecorder that keeps the debug messages that
+// are written to ProcessMonitor.
+var flightRecorder atomic.Pointer[FlightRecorder]
+
+// SetFlightRecorder installs a FlightRecorder that keeps every debug message
+// that is written to ProcessMonitor or by the package-level functions,
+// whichever backend is active. While a FlightRecorder is installed, debug
+// messages are formatted even if the output discards them. Passing nil
+// removes the FlightRecorder, which is the default.
+//
+//	procmon.SetFlightRecorder(procmon.NewFlightRecorder(procmon.FlightRecorderOptions{}))
+func SetFlightRecorder(fr *FlightRecorder) {
+	flightRecorder.Store(fr)
+}
+
+// CurrentFlightRecorder returns the FlightRecorder that was installed by
+// SetFlightRecorder, or nil.
+func CurrentFlightRecorder() *FlightRecorder {
+	return flightRecorder.Load()
+}
@@ -0,0 +1,203 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mfcollins3/go-procmon/internal/goid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func messagesOf(records []FlightRecord) []string {
+	messages := make([]string, len(records))
+	for i, r := range records {
+		messages[i] = r.Message
+	}
+
+	return messages
+}
+
+var _ = Describe("FlightRecorder", func() {
+	It("keeps the most recent debug messages", func() {
+		fr := NewFlightRecorder(FlightRecorderOptions{MaxMessages: 3})
+		for _, m := range []string{"one", "two", "three", "four", "five"} {
+			fr.WriteString(m)
+		}
+
+		records := fr.Records()
+		Expect(messagesOf(records)).To(Equal([]string{"three", "four", "five"}))
+		Expect(records[0].Sequence).To(Equal(uint64(3)))
+		Expect(records[2].Sequence).To(Equal(uint64(5)))
+	})
+
+	It("keeps the most recent bytes", func() {
+		fr := NewFlightRecorder(FlightRecorderOptions{MaxBytes: 10})
+		for _, m := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+			fr.WriteString(m)
+		}
+
+		Expect(messagesOf(fr.Records())).To(Equal([]string{"cccc", "dddd"}))
+
+		fr.WriteString(strings.Repeat("x", 20))
+		Expect(fr.Records()).To(HaveLen(1))
+	})
+
+	It("keeps DefaultFlightRecorderSize messages by default", func() {
+		fr := NewFlightRecorder(FlightRecorderOptions{})
+		for i := 0; i < DefaultFlightRecorderSize+10; i++ {
+			fr.WriteString("message")
+		}
+
+		Expect(fr.Records()).To(HaveLen(DefaultFlightRecorderSize))
+	})
+
+	It("keeps the original time, goroutine, level, and component", func() {
+		fr := NewFlightRecorder(FlightRecorderOptions{})
+		at := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		fr.WriteEntry(&Entry{Time: at, Level: LevelWarn, Component: "db", Message: "db: slow"})
+
+		r := fr.Records()[0]
+		Expect(r.Time).To(Equal(at))
+		Expect(r.Goroutine).To(Equal(goid.Current()))
+		Expect(r.Level).To(Equal(LevelWarn))
+		Expect(r.Component).To(Equal("db"))
+		Expect(r.String()).To(Equal("2016-01-02T03:04:05.000000Z #1 g" +
+			strconv.FormatUint(goid.Current(), 10) + " db: slow"))
+	})
+
+	It("applies the current Redactor", func() {
+		defer SetRedactor(nil)
+		SetRedactor(NewRedactor(RedactEmailAddresses))
+
+		fr := NewFlightRecorder(FlightRecorderOptions{})
+		fr.WriteString("user jane@example.com logged in")
+		Expect(fr.Records()[0].Message).NotTo(ContainSubstring("jane@example.com"))
+	})
+
+	It("discards the kept messages when reset", func() {
+		fr := NewFlightRecorder(FlightRecorderOptions{})
+		fr.WriteString("one")
+		fr.Reset()
+		fr.WriteString("two")
+		Expect(fr.Records()).To(HaveLen(1))
+		Expect(fr.Records()[0].Sequence).To(Equal(uint64(2)))
+	})
+
+	Describe("Dump", func() {
+		var fr *FlightRecorder
+
+		BeforeEach(func() {
+			fr = NewFlightRecorder(FlightRecorderOptions{})
+			fr.WriteString("one")
+			fr.WriteString("two")
+		})
+
+		It("writes one line per message", func() {
+			var b bytes.Buffer
+			Expect(fr.Dump(&b)).To(Succeed())
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(HaveSuffix(" one"))
+			Expect(lines[1]).To(ContainSubstring(" #2 g"))
+		})
+
+		It("writes a support bundle file", func() {
+			dir, err := os.MkdirTemp("", "procmon")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "flight.log")
+			Expect(fr.DumpToFile(path)).To(Succeed())
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(" two\n"))
+		})
+
+		It("writes the messages when the program panics", func() {
+			var b bytes.Buffer
+			Expect(func() {
+				defer fr.DumpOnPanic(&b)
+				panic(errors.New("boom"))
+			}).To(Panic())
+			Expect(b.String()).To(ContainSubstring(" two\n"))
+		})
+
+		It("does nothing when the program does not panic", func() {
+			var b bytes.Buffer
+			func() {
+				defer fr.DumpOnPanic(&b)
+			}()
+			Expect(b.Len()).To(BeZero())
+		})
+	})
+
+	Context("when it is installed", func() {
+		var original Writer
+
+		BeforeEach(func() {
+			original = Output()
+		})
+
+		AfterEach(func() {
+			SetOutput(original)
+			SetFlightRecorder(nil)
+		})
+
+		It("keeps debug messages even if the output discards them", func() {
+			SetOutput(nil)
+			fr := NewFlightRecorder(FlightRecorderOptions{})
+			SetFlightRecorder(fr)
+			Expect(CurrentFlightRecorder()).To(Equal(fr))
+			Expect(Enabled(LevelDebug)).To(BeTrue())
+
+			ProcessMonitor.WriteString("plain")
+			Named("db").Warnf("slow query")
+
+			records := fr.Records()
+			Expect(messagesOf(records)).To(Equal([]string{"plain", "db: WARN: slow query"}))
+			Expect(records[1].Component).To(Equal("db"))
+			Expect(records[1].Level).To(Equal(LevelWarn))
+		})
+
+		It("keeps debug messages that are written to the output", func() {
+			recorder := &recordingWriter{}
+			SetOutput(recorder)
+			fr := NewFlightRecorder(FlightRecorderOptions{})
+			SetFlightRecorder(fr)
+
+			Infof("hello")
+			Expect(recorder.Messages()).To(Equal([]string{"INFO: hello"}))
+			Expect(messagesOf(fr.Records())).To(Equal([]string{"INFO: hello"}))
+		})
+	})
+})
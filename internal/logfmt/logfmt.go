@@ -0,0 +1,145 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package logfmt renders the fields of log entries as key=value pairs. It is
+// shared by the adapters that forward log entries from other logging
+// libraries so that their debug messages look the same in Process Monitor:
+// the message, then the fields, then the error, then the caller.
+//
+//	INFO: query failed table=users error=timeout caller=store/query.go:42
+package logfmt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	procmon "github.com/mfcollins3/go-procmon"
+)
+
+// The levels that other logging libraries have but procmon does not name.
+// Every adapter writes them with the same procmon levels so that, for
+// example, a trace entry from logrus and a trace event from zerolog have the
+// same severity and the same threshold.
+const (
+	// LevelTrace is the level of trace entries, which are more verbose than
+	// debug entries.
+	LevelTrace = procmon.LevelDebug - 4
+
+	// LevelPanic is the level of entries that are written before the
+	// program panics.
+	LevelPanic = procmon.LevelError + 4
+
+	// LevelFatal is the level of entries that are written before the
+	// program exits.
+	LevelFatal = procmon.LevelError + 8
+)
+
+const (
+	// ErrorKey is the key of the error of a log entry.
+	ErrorKey = "error"
+
+	// CallerKey is the key of the source location that wrote a log entry.
+	CallerKey = "caller"
+)
+
+// Append writes a key=value pair to b. The pair is separated from the text
+// before it by a space. The key and value are quoted if necessary.
+func Append(b *bytes.Buffer, key, value string) {
+	if 0 < b.Len() {
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(Quote(key))
+	b.WriteByte('=')
+	b.WriteString(Quote(value))
+}
+
+// AppendError writes the error=message pair to b. Nothing is written if err
+// is nil.
+func AppendError(b *bytes.Buffer, err error) {
+	if nil != err {
+		Append(b, ErrorKey, err.Error())
+	}
+}
+
+// AppendCaller writes the caller=dir/file.go:line pair to b. Nothing is
+// written if file is empty.
+func AppendCaller(b *bytes.Buffer, file string, line int) {
+	if "" != file {
+		Append(b, CallerKey, Caller(file, line))
+	}
+}
+
+// Caller formats a source location as the name of the file and the
+// directory that contains it, followed by the line number, such as
+// "store/query.go:42".
+func Caller(file string, line int) string {
+	file = strings.ReplaceAll(file, `\`, "/")
+	if i := strings.LastIndexByte(file, '/'); 0 <= i {
+		if j := strings.LastIndexByte(file[:i], '/'); 0 <= j {
+			file = file[j+1:]
+		}
+	}
+
+	return file + ":" + strconv.Itoa(line)
+}
+
+// Quote quotes s if it is empty or contains spaces, quotes, equal signs,
+// control characters, or invalid UTF-8.
+func Quote(s string) string {
+	if "" == s {
+		return `""`
+	}
+
+	for _, r := range s {
+		if ' ' >= r || '"' == r || '=' == r || 0x7f == r || utf8.RuneError == r {
+			return strconv.Quote(s)
+		}
+	}
+
+	return s
+}
+
+// Value formats the value of a field as text. Errors are formatted using
+// their messages and times are formatted using RFC 3339.
+func Value(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprintf("%+v", v)
+}
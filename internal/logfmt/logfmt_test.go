@@ -0,0 +1,104 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package logfmt
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Append", func() {
+	It("separates pairs with spaces", func() {
+		var b bytes.Buffer
+		b.WriteString("msg")
+		Append(&b, "a", "1")
+		Append(&b, "b", "two words")
+		Expect(b.String()).To(Equal(`msg a=1 b="two words"`))
+	})
+
+	It("does not add a space to an empty buffer", func() {
+		var b bytes.Buffer
+		Append(&b, "a", "1")
+		Expect(b.String()).To(Equal("a=1"))
+	})
+
+	It("writes the error and the caller", func() {
+		var b bytes.Buffer
+		b.WriteString("msg")
+		AppendError(&b, nil)
+		AppendError(&b, errors.New("access denied"))
+		AppendCaller(&b, "", 0)
+		AppendCaller(&b, "/src/app/store/query.go", 42)
+		Expect(b.String()).To(Equal(`msg error="access denied" caller=store/query.go:42`))
+	})
+})
+
+var _ = Describe("Caller", func() {
+	It("keeps the file name and its directory", func() {
+		Expect(Caller("/src/app/store/query.go", 42)).To(Equal("store/query.go:42"))
+		Expect(Caller(`C:\src\app\main.go`, 7)).To(Equal("app/main.go:7"))
+		Expect(Caller("main.go", 1)).To(Equal("main.go:1"))
+	})
+})
+
+var _ = Describe("Quote", func() {
+	It("quotes values that need quoting", func() {
+		Expect(Quote("")).To(Equal(`""`))
+		Expect(Quote("plain")).To(Equal("plain"))
+		Expect(Quote("a b")).To(Equal(`"a b"`))
+		Expect(Quote("a=b")).To(Equal(`"a=b"`))
+		Expect(Quote(`say "hi"`)).To(Equal(`"say \"hi\""`))
+		Expect(Quote("line\nbreak")).To(Equal(`"line\nbreak"`))
+	})
+})
+
+var _ = Describe("Value", func() {
+	It("formats values as text", func() {
+		t := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		Expect(Value(nil)).To(Equal("<nil>"))
+		Expect(Value("text")).To(Equal("text"))
+		Expect(Value(42)).To(Equal("42"))
+		Expect(Value(errors.New("failed"))).To(Equal("failed"))
+		Expect(Value(t)).To(Equal("2016-01-02T03:04:05Z"))
+		Expect(Value(1500 * time.Millisecond)).To(Equal("1.5s"))
+		Expect(Value(struct{ A int }{1})).To(Equal("{A:1}"))
+	})
+})
+
+var _ = Describe("Levels", func() {
+	It("orders the levels around the procmon levels", func() {
+		Expect(LevelTrace).To(BeNumerically("<", procmon.LevelDebug))
+		Expect(LevelPanic).To(BeNumerically(">", procmon.LevelError))
+		Expect(LevelFatal).To(BeNumerically(">", LevelPanic))
+		Expect(LevelTrace.String()).To(Equal("DEBUG-4"))
+		Expect(LevelPanic.String()).To(Equal("ERROR+4"))
+		Expect(LevelFatal.String()).To(Equal("ERROR+8"))
+	})
+})
@@ -0,0 +1,154 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a debug message. The levels are spaced apart and
+// use the same values as the log/slog package so that levels can be
+// converted between the two packages.
+type Level int
+
+const (
+	// LevelDebug is used for detailed messages that are only useful while
+	// diagnosing a problem.
+	LevelDebug Level = -4
+
+	// LevelInfo is used for messages describing normal operation. Messages
+	// that are written without a level use LevelInfo.
+	LevelInfo Level = 0
+
+	// LevelWarn is used for messages describing unexpected conditions that
+	// the program can recover from.
+	LevelWarn Level = 4
+
+	// LevelError is used for messages describing failures.
+	LevelError Level = 8
+)
+
+// String returns the name of the level. Levels between the named levels are
+// described relative to the named level below them, such as "INFO+2".
+func (l Level) String() string {
+	name := func(base string, offset Level) string {
+		if 0 == offset {
+			return base
+		}
+
+		return fmt.Sprintf("%s%+d", base, int(offset))
+	}
+
+	switch {
+	case l < LevelInfo:
+		return name("DEBUG", l-LevelDebug)
+	case l < LevelWarn:
+		return name("INFO", l-LevelInfo)
+	case l < LevelError:
+		return name("WARN", l-LevelWarn)
+	}
+
+	return name("ERROR", l-LevelError)
+}
+
+// ParseLevel converts the name of a level, such as "debug" or "WARN", into a
+// Level. ParseLevel accepts the strings that are returned by Level.String.
+func ParseLevel(s string) (Level, error) {
+	name, offset := s, 0
+	if i := strings.IndexAny(s, "+-"); 0 < i {
+		n, err := strconv.Atoi(s[i:])
+		if nil != err {
+			return 0, fmt.Errorf("procmon: invalid level %q", s)
+		}
+
+		name, offset = s[:i], n
+	}
+
+	var l Level
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		l = LevelDebug
+	case "INFO":
+		l = LevelInfo
+	case "WARN", "WARNING":
+		l = LevelWarn
+	case "ERROR":
+		l = LevelError
+	default:
+		return 0, fmt.Errorf("procmon: invalid level %q", s)
+	}
+
+	return l + Level(offset), nil
+}
+
+// DefaultLevelPrefix returns the severity prefix that is added to debug
+// messages that are written using the leveled functions, such as "WARN: ".
+func DefaultLevelPrefix(l Level) string {
+	return l.String() + ": "
+}
+
+// threshold is the global minimum level of the debug messages that are
+// written by the leveled functions.
+var threshold int64 = int64(LevelDebug)
+
+// Threshold returns the global minimum level of the debug messages that are
+// written by the leveled functions.
+func Threshold() Level {
+	return Level(atomic.LoadInt64(&threshold))
+}
+
+// SetThreshold sets the global minimum level of the debug messages that are
+// written by the leveled functions. Loggers that have their own threshold
+// are not affected. The default threshold is LevelDebug.
+func SetThreshold(l Level) {
+	atomic.StoreInt64(&threshold, int64(l))
+}
+
+// levelPrefix holds the function that formats the global severity prefix.
+var levelPrefix atomic.Value
+
+// SetLevelPrefix sets the function that returns the severity prefix that is
+// added to the debug messages that are written by the leveled functions.
+// Loggers that have their own prefix function are not affected. Passing nil
+// restores DefaultLevelPrefix. To write messages without a prefix, pass a
+// function that returns an empty string.
+func SetLevelPrefix(f func(Level) string) {
+	if nil == f {
+		f = DefaultLevelPrefix
+	}
+
+	levelPrefix.Store(f)
+}
+
+func globalLevelPrefix() func(Level) string {
+	if f, ok := levelPrefix.Load().(func(Level) string); ok {
+		return f
+	}
+
+	return DefaultLevelPrefix
+}
@@ -25,67 +25,39 @@ SOFTWARE.
 package procmon
 
 import (
-	"bytes"
-	"io"
-
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
-type mockProcessMonitor struct {
-	message string
-}
-
-func (m *mockProcessMonitor) Write(message string) error {
-	m.message = message
-	return nil
-}
-
-var _ = Describe("processMonitorWriter", func() {
-	const testMessage = "Hello, World!"
-	var mock = &mockProcessMonitor{}
-	var writer = &processMonitorWriter{mock}
-
-	Describe("Write", func() {
-		var length int
-		var err error
-
-		BeforeEach(func() {
-			length, err =
-				writer.Write(bytes.NewBufferString(testMessage).Bytes())
-		})
-
-		It("writes the message to Process Monitor", func() {
-			Expect(mock.message).To(Equal(testMessage))
-		})
-
-		It("returns the length of the string", func() {
-			Expect(length).To(Equal(len(testMessage)))
+var _ = Describe("Level", func() {
+	Describe("String", func() {
+		It("returns the name of the level", func() {
+			Expect(LevelDebug.String()).To(Equal("DEBUG"))
+			Expect(LevelInfo.String()).To(Equal("INFO"))
+			Expect(LevelWarn.String()).To(Equal("WARN"))
+			Expect(LevelError.String()).To(Equal("ERROR"))
 		})
 
-		It("does not return an error", func() {
-			Expect(err).To(BeNil())
+		It("describes levels between the named levels", func() {
+			Expect((LevelInfo + 2).String()).To(Equal("INFO+2"))
+			Expect((LevelDebug - 1).String()).To(Equal("DEBUG-1"))
 		})
 	})
 
-	Describe("WriteString", func() {
-		var length int
-		var err error
-
-		BeforeEach(func() {
-			length, err = io.WriteString(writer, testMessage)
-		})
-
-		It("writes the message to Process Monitor", func() {
-			Expect(mock.message).To(Equal(testMessage))
+	Describe("ParseLevel", func() {
+		It("parses the names of the levels", func() {
+			Expect(ParseLevel("debug")).To(Equal(LevelDebug))
+			Expect(ParseLevel("Warning")).To(Equal(LevelWarn))
+			Expect(ParseLevel("ERROR")).To(Equal(LevelError))
 		})
 
-		It("returns the length of the string", func() {
-			Expect(length).To(Equal(len(testMessage)))
+		It("parses levels between the named levels", func() {
+			Expect(ParseLevel("INFO+2")).To(Equal(LevelInfo + 2))
 		})
 
-		It("does not return an error", func() {
-			Expect(err).To(BeNil())
+		It("returns an error for unknown levels", func() {
+			_, err := ParseLevel("verbose")
+			Expect(err).To(HaveOccurred())
 		})
 	})
 })
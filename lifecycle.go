@@ -0,0 +1,419 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Options configures the connection that is opened by Open.
+type Options struct {
+	// Backend is the specification of the backends to open, using the same
+	// format as the PROCMON_BACKEND environment variable. If Backend is
+	// empty, the PROCMON_BACKEND environment variable is used, and if that
+	// is not set, the default backend for the platform is used.
+	Backend string
+
+	// RetryInterval enables background detection. If the backends cannot be
+	// opened, Open returns the error and then tries to open the backends
+	// again every RetryInterval until they open or Close is called. Debug
+	// messages are discarded until the backends are opened. If
+	// RetryInterval is zero, Open does not retry.
+	RetryInterval time.Duration
+
+	// Opener opens the backends that are described by a backend
+	// specification. If Opener is nil, OpenBackend is used. Opener can be
+	// replaced to test code that depends on Process Monitor being started
+	// after the program.
+	Opener func(spec string) (Backend, error)
+
+	// ReplayFlightRecorder writes the debug messages that are kept by the
+	// current FlightRecorder to the backends when background detection opens
+	// them, before any new debug messages are written. Each replayed
+	// message is marked with "[replayed]", its sequence number, and the
+	// time that it was originally written. This captures the debug messages
+	// that were written during startup, before Process Monitor was running.
+	// Debug messages are not replayed if the backends are opened by Open
+	// or Reopen.
+	ReplayFlightRecorder bool
+}
+
+var (
+	connectionMu sync.Mutex
+	current      *connection
+)
+
+// Open opens the backends that are described by opts and makes
+// ProcessMonitor write to them. Any connection that was opened by a
+// previous call to Open is closed first. The returned Writer is the new
+// value of Output, unless a Writer was installed using SetOutput; that
+// Writer is kept and the returned Writer is only used by the caller.
+//
+// If the backends cannot be opened, Open returns an error and debug messages
+// are discarded. If opts.RetryInterval is set, Open keeps trying to open the
+// backends in the background and debug messages are written to the backends
+// as soon as they are opened.
+func Open(opts Options) (Writer, error) {
+	connectionMu.Lock()
+	defer connectionMu.Unlock()
+
+	previous := current
+	if nil != previous {
+		previous.close()
+	}
+
+	current = newConnection(opts)
+	err := current.open()
+	swapOutput(previous, current)
+	return current, err
+}
+
+// Close closes the backends that were opened by Open and stops background
+// detection. After Close returns, debug messages that are written to
+// ProcessMonitor are discarded. A Writer that was installed using SetOutput
+// is left in place.
+func Close() error {
+	connectionMu.Lock()
+	defer connectionMu.Unlock()
+
+	if nil == current {
+		return nil
+	}
+
+	swapOutput(current, nil)
+	err := current.close()
+	current = nil
+	return err
+}
+
+// Reopen closes the backends that were opened by Open and opens them again
+// using the same options. Reopen is used to start writing to Process Monitor
+// after Process Monitor is started or restarted. If Open has not been
+// called, Reopen opens the default backends.
+func Reopen() error {
+	connectionMu.Lock()
+	c := current
+	connectionMu.Unlock()
+
+	if nil == c {
+		_, err := Open(Options{})
+		return err
+	}
+
+	return c.reopen()
+}
+
+// connection is the Writer that is installed as the output by Open. The
+// connection writes debug messages to its backend, or discards them while
+// the backend is not open.
+type connection struct {
+	opts   Options
+	spec   string
+	env    bool
+	stats  connectionStats
+	mu     sync.RWMutex
+	writer *processMonitorWriter
+	stop   chan struct{}
+	closed bool
+
+	// replayedFrom and replayedThrough identify the messages that were
+	// replayed from a FlightRecorder when the backends were opened.
+	replayedFrom    *FlightRecorder
+	replayedThrough uint64
+}
+
+func newConnection(opts Options) *connection {
+	spec, env := opts.Backend, false
+	if "" == spec {
+		spec = os.Getenv(BackendEnvironmentVariable)
+		env = "" != spec
+	}
+
+	if "" == spec {
+		spec = defaultBackend
+	}
+
+	if nil == opts.Opener {
+		opts.Opener = openBackends
+	}
+
+	return &connection{opts: opts, spec: spec, env: env}
+}
+
+// reopenEnvironmentBackend reopens the current connection if it was opened
+// from the PROCMON_BACKEND environment variable and the environment variable
+// names the backend, which has just been registered.
+func reopenEnvironmentBackend(name string) {
+	connectionMu.Lock()
+	c := current
+	connectionMu.Unlock()
+
+	if nil == c || !c.env || !specNames(c.spec, name) {
+		return
+	}
+
+	if err := c.reopen(); nil != err {
+		diagnosef("reopening %s failed: %v", c.spec, err)
+	}
+}
+
+func (c *connection) Write(p []byte) (n int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	}
+
+	if nil == c.writer {
+		return len(p), nil
+	}
+
+	n, err = c.writer.Write(p)
+	c.stats.wrote(err)
+	return n, err
+}
+
+func (c *connection) WriteString(s string) (n int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	}
+
+	if nil == c.writer {
+		return len(s), nil
+	}
+
+	n, err = c.writer.WriteString(s)
+	c.stats.wrote(err)
+	return n, err
+}
+
+// writeRecorded writes a debug message that fr recorded with sequence number
+// seq by calling write with the writer for the backends. The message is
+// skipped if it was already replayed when the backends were opened, which
+// happens when the message was recorded before the backends were opened but
+// reaches the connection after. n is the length of the message.
+func (c *connection) writeRecorded(
+	fr *FlightRecorder,
+	seq uint64,
+	n int,
+	write func(w *processMonitorWriter) (int, error),
+) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	}
+
+	if nil == c.writer ||
+		(0 != seq && fr == c.replayedFrom && seq <= c.replayedThrough) {
+		return n, nil
+	}
+
+	n, err := write(c.writer)
+	c.stats.wrote(err)
+	return n, err
+}
+
+// discards returns true while the backends are not open.
+func (c *connection) discards() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return nil == c.writer
+}
+
+// open tries to open the backends and starts background detection if they
+// cannot be opened and a retry interval was configured.
+func (c *connection) open() error {
+	err := c.tryOpen(false)
+	if nil == err || 0 >= c.opts.RetryInterval {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed && nil == c.writer && nil == c.stop {
+		c.stop = make(chan struct{})
+		go c.retry(c.stop)
+	}
+
+	return err
+}
+
+// tryOpen opens the backends once. If some of the backends are opened, they
+// are used even though an error is returned for the others. If replay is
+// true, the debug messages that are kept by the current FlightRecorder are
+// written to the backends after they are opened.
+func (c *connection) tryOpen(replay bool) error {
+	if "" == c.spec {
+		err := errors.New("procmon: no backends were specified")
+		c.stats.openFailed(err)
+		return err
+	}
+
+	b, err := c.opts.Opener(c.spec)
+	if nil == b {
+		c.stats.openFailed(err)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || nil != c.writer {
+		b.Close()
+		return err
+	}
+
+	c.writer = &processMonitorWriter{b}
+	c.stats.opened(err)
+	diagnosef("Process Monitor debug logging is enabled: %s", c.spec)
+	if replay {
+		c.replay()
+	}
+
+	return err
+}
+
+// replay writes the debug messages that are kept by the current
+// FlightRecorder to the backends. The caller must hold the write lock, which
+// keeps new debug messages from being written until the replay is done, and
+// must have just installed the writer, so that every message that is
+// recorded after the snapshot is written by its own write. The messages
+// were redacted when they were recorded, so the Redactor is skipped.
+func (c *connection) replay() {
+	fr := CurrentFlightRecorder()
+	if nil == fr {
+		return
+	}
+
+	records := fr.Records()
+	if 0 < len(records) {
+		c.replayedFrom = fr
+		c.replayedThrough = records[len(records)-1].Sequence
+	}
+
+	for _, r := range records {
+		text := r.replayed()
+		_, err := c.writer.write(text, text)
+		c.stats.wrote(err)
+	}
+
+	diagnosef("Replayed %d debug messages from the flight recorder", len(records))
+}
+
+// retry tries to open the backends every RetryInterval until they are
+// opened or stop is closed.
+func (c *connection) retry(stop chan struct{}) {
+	ticker := time.NewTicker(c.opts.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.tryOpen(c.opts.ReplayFlightRecorder)
+
+		c.mu.Lock()
+		connected := nil != c.writer
+		if connected && c.stop == stop {
+			c.stop = nil
+		}
+		c.mu.Unlock()
+
+		if connected {
+			return
+		}
+	}
+}
+
+func (c *connection) reopen() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+
+	err := c.closeBackend()
+	c.mu.Unlock()
+
+	if openErr := c.open(); nil != openErr {
+		return openErr
+	}
+
+	return err
+}
+
+func (c *connection) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	return c.closeBackend()
+}
+
+// closeBackend stops background detection and closes the backends. The
+// caller must hold the write lock.
+func (c *connection) closeBackend() error {
+	if nil != c.stop {
+		close(c.stop)
+		c.stop = nil
+	}
+
+	if nil == c.writer {
+		return nil
+	}
+
+	err := c.writer.backend.Close()
+	c.writer = nil
+	c.stats.closed()
+	return err
+}
+
+func (c *connection) status() StatusReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := c.stats.report()
+	report.Backend = c.spec
+	report.Active = nil != c.writer
+	return report
+}
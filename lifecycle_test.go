@@ -0,0 +1,370 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeOpener opens mock backends and fails until it is told to succeed.
+type fakeOpener struct {
+	mu       sync.Mutex
+	fail     bool
+	calls    int
+	specs    []string
+	backends []*mockProcessMonitor
+}
+
+func (o *fakeOpener) Open(spec string) (Backend, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.calls++
+	o.specs = append(o.specs, spec)
+	if o.fail {
+		return nil, errors.New("device not found")
+	}
+
+	b := &mockProcessMonitor{}
+	o.backends = append(o.backends, b)
+	return b, nil
+}
+
+func (o *fakeOpener) SetFail(fail bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.fail = fail
+}
+
+func (o *fakeOpener) Calls() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.calls
+}
+
+// waitUntilActive waits until the connection that was opened by Open has
+// installed the writer for its backends. The flight recorder is replayed
+// while the writer is installed, so the replay is done when this returns.
+func waitUntilActive() {
+	Eventually(func() string {
+		if s := Status(); s.Active {
+			return s.Backend
+		}
+
+		return ""
+	}).Should(Equal("mock"))
+}
+
+func (o *fakeOpener) Backend(i int) *mockProcessMonitor {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if i >= len(o.backends) {
+		return nil
+	}
+
+	return o.backends[i]
+}
+
+var _ = Describe("Open", func() {
+	var original Writer
+	var opener *fakeOpener
+
+	BeforeEach(func() {
+		original = Output()
+		opener = &fakeOpener{}
+	})
+
+	AfterEach(func() {
+		Close()
+		SetOutput(original)
+	})
+
+	It("writes debug messages to the opened backend", func() {
+		w, err := Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(err).To(BeNil())
+		Expect(Output()).To(Equal(w))
+
+		io.WriteString(ProcessMonitor, "Hello, World!")
+		Expect(opener.specs).To(Equal([]string{"mock"}))
+		Expect(opener.Backend(0).messages).To(Equal([]string{"Hello, World!"}))
+	})
+
+	It("closes the previous connection", func() {
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(opener.Backend(0).closed).To(BeTrue())
+		Expect(opener.Backend(1).closed).To(BeFalse())
+	})
+
+	Context("when the backend cannot be opened", func() {
+		BeforeEach(func() {
+			opener.SetFail(true)
+		})
+
+		It("returns the error", func() {
+			_, err := Open(Options{Backend: "mock", Opener: opener.Open})
+			Expect(err).To(MatchError("device not found"))
+		})
+
+		It("discards debug messages", func() {
+			Open(Options{Backend: "mock", Opener: opener.Open})
+			n, err := io.WriteString(ProcessMonitor, "Hello, World!")
+			Expect(n).To(Equal(13))
+			Expect(err).To(BeNil())
+		})
+
+		It("does not retry by default", func() {
+			Open(Options{Backend: "mock", Opener: opener.Open})
+			Consistently(opener.Calls, 50*time.Millisecond).Should(Equal(1))
+		})
+
+		It("switches to the backend once it opens when retrying", func() {
+			Open(Options{
+				Backend:       "mock",
+				RetryInterval: time.Millisecond,
+				Opener:        opener.Open,
+			})
+			Eventually(opener.Calls).Should(BeNumerically(">", 2))
+
+			opener.SetFail(false)
+			waitUntilActive()
+
+			io.WriteString(ProcessMonitor, "Hello, World!")
+			Expect(opener.Backend(0).messages).To(Equal([]string{"Hello, World!"}))
+
+			calls := opener.Calls()
+			Consistently(opener.Calls, 20*time.Millisecond).Should(Equal(calls))
+		})
+
+		Context("when the flight recorder is replayed", func() {
+			var fr *FlightRecorder
+
+			BeforeEach(func() {
+				fr = NewFlightRecorder(FlightRecorderOptions{})
+				SetFlightRecorder(fr)
+			})
+
+			AfterEach(func() {
+				SetFlightRecorder(nil)
+			})
+
+			It("writes the kept messages before new messages", func() {
+				Open(Options{
+					Backend:              "mock",
+					RetryInterval:        time.Millisecond,
+					Opener:               opener.Open,
+					ReplayFlightRecorder: true,
+				})
+				io.WriteString(ProcessMonitor, "starting")
+				Infof("loading")
+
+				opener.SetFail(false)
+				waitUntilActive()
+
+				io.WriteString(ProcessMonitor, "running")
+
+				records := fr.Records()
+				messages := opener.Backend(0).messages
+				Expect(messages).To(HaveLen(3))
+				Expect(messages[0]).To(Equal(records[0].replayed()))
+				Expect(messages[0]).To(MatchRegexp(`^\[replayed #1 \d{4}-\d\d-\d\dT\S+ g\d+\] starting$`))
+				Expect(messages[1]).To(HavePrefix("[replayed #2 "))
+				Expect(messages[1]).To(HaveSuffix("] INFO: loading"))
+				Expect(messages[2]).To(Equal("running"))
+			})
+
+			It("does not replay the messages if the backend opens right away", func() {
+				io.WriteString(ProcessMonitor, "starting")
+				opener.SetFail(false)
+				Open(Options{
+					Backend:              "mock",
+					RetryInterval:        time.Millisecond,
+					Opener:               opener.Open,
+					ReplayFlightRecorder: true,
+				})
+
+				io.WriteString(ProcessMonitor, "running")
+				Expect(opener.Backend(0).messages).To(Equal([]string{"running"}))
+			})
+
+			It("does not redact the replayed messages again", func() {
+				redactor := NewRedactor(RedactionRule{
+					Pattern: regexp.MustCompile(`secret`),
+				})
+				SetRedactor(redactor)
+				defer SetRedactor(nil)
+
+				Open(Options{
+					Backend:              "mock",
+					RetryInterval:        time.Millisecond,
+					Opener:               opener.Open,
+					ReplayFlightRecorder: true,
+				})
+				io.WriteString(ProcessMonitor, "password=secret")
+
+				opener.SetFail(false)
+				waitUntilActive()
+
+				messages := opener.Backend(0).messages
+				Expect(messages).To(HaveLen(1))
+				Expect(messages[0]).To(HaveSuffix("] password=[REDACTED]"))
+				Expect(redactor.Stats().Redactions).To(Equal(uint64(1)))
+			})
+
+			It("does not write a replayed message again", func() {
+				Open(Options{
+					Backend:              "mock",
+					RetryInterval:        time.Millisecond,
+					Opener:               opener.Open,
+					ReplayFlightRecorder: true,
+				})
+
+				// The message is recorded before the backend is opened but
+				// reaches the connection after it was replayed.
+				seq := fr.record(newEntry("late"))
+				opener.SetFail(false)
+				waitUntilActive()
+
+				c := Output().(*connection)
+				n, err := c.writeRecorded(fr, seq, 4, func(w *processMonitorWriter) (int, error) {
+					return w.WriteString("late")
+				})
+				Expect(n).To(Equal(4))
+				Expect(err).To(BeNil())
+				io.WriteString(ProcessMonitor, "running")
+
+				messages := opener.Backend(0).messages
+				Expect(messages).To(HaveLen(2))
+				Expect(messages[0]).To(HaveSuffix("] late"))
+				Expect(messages[1]).To(Equal("running"))
+			})
+
+			It("does not replay the messages unless asked to", func() {
+				Open(Options{
+					Backend:       "mock",
+					RetryInterval: time.Millisecond,
+					Opener:        opener.Open,
+				})
+				io.WriteString(ProcessMonitor, "starting")
+
+				opener.SetFail(false)
+				waitUntilActive()
+
+				io.WriteString(ProcessMonitor, "running")
+				Expect(opener.Backend(0).messages).To(Equal([]string{"running"}))
+			})
+		})
+
+		It("stops retrying when closed", func() {
+			Open(Options{
+				Backend:       "mock",
+				RetryInterval: time.Millisecond,
+				Opener:        opener.Open,
+			})
+			Expect(Close()).To(Succeed())
+
+			calls := opener.Calls()
+			Consistently(opener.Calls, 20*time.Millisecond).
+				Should(BeNumerically("<=", calls+1))
+		})
+	})
+})
+
+var _ = Describe("Close", func() {
+	var original Writer
+
+	BeforeEach(func() {
+		original = Output()
+	})
+
+	AfterEach(func() {
+		SetOutput(original)
+	})
+
+	It("closes the backend and discards later debug messages", func() {
+		opener := &fakeOpener{}
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(Close()).To(Succeed())
+		Expect(opener.Backend(0).closed).To(BeTrue())
+		Expect(Output()).To(Equal(&nullProcessMonitor{}))
+	})
+
+	It("leaves a custom output in place", func() {
+		recorder := &recordingWriter{}
+		SetOutput(recorder)
+		Expect(Close()).To(Succeed())
+
+		opener := &fakeOpener{}
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(Output()).To(Equal(recorder))
+		Expect(Close()).To(Succeed())
+		Expect(opener.Backend(0).closed).To(BeTrue())
+
+		io.WriteString(ProcessMonitor, "after")
+		Expect(recorder.Messages()).To(Equal([]string{"after"}))
+	})
+
+	It("leaves an output that replaced the connection in place", func() {
+		opener := &fakeOpener{}
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		recorder := &recordingWriter{}
+		SetOutput(recorder)
+		Expect(Close()).To(Succeed())
+		Expect(Output()).To(Equal(recorder))
+	})
+})
+
+var _ = Describe("Reopen", func() {
+	var original Writer
+
+	BeforeEach(func() {
+		original = Output()
+	})
+
+	AfterEach(func() {
+		Close()
+		SetOutput(original)
+	})
+
+	It("closes the backend and opens it again", func() {
+		opener := &fakeOpener{}
+		w, _ := Open(Options{Backend: "mock", Opener: opener.Open})
+		Expect(Reopen()).To(Succeed())
+		Expect(opener.Backend(0).closed).To(BeTrue())
+
+		io.WriteString(w, "Hello, World!")
+		Expect(opener.Backend(1).messages).To(Equal([]string{"Hello, World!"}))
+	})
+})
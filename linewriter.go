@@ -0,0 +1,208 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LineWriter buffers text that is written to it and writes one debug message
+// to the underlying Writer for each complete line. Partial lines are held
+// until a newline is written, Flush or Close is called, the idle timeout
+// expires, or the partial line grows to maxLineMessages times the limit of
+// the current MessageSizer. Each line is written to the underlying Writer as
+// a whole, so a long line is split or truncated by the MessageSizer the same
+// way no matter how it was written. LineWriter makes the output of io.Copy,
+// text/template, encoding/json, and other code that writes text in pieces
+// readable in the Process Monitor log.
+//
+// Line endings are removed from the debug messages and empty lines are not
+// written. LineWriter is safe for concurrent use.
+type LineWriter struct {
+	mu          sync.Mutex
+	w           Writer
+	buf         []byte
+	idleTimeout time.Duration
+	timer       *time.Timer
+	closed      bool
+}
+
+// maxLineMessages is the number of debug messages at the limit of the current
+// MessageSizer that a partial line may fill before it is written, so that the
+// buffer does not grow without bound.
+const maxLineMessages = 16
+
+// NewLineWriter returns a LineWriter that writes complete lines to w.
+func NewLineWriter(w Writer) *LineWriter {
+	return &LineWriter{w: w}
+}
+
+// SetIdleTimeout sets how long a partial line is held before it is written
+// to the underlying Writer. A timeout of zero, which is the default, holds
+// partial lines until a newline is written or Flush or Close is called.
+func (lw *LineWriter) SetIdleTimeout(d time.Duration) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	lw.idleTimeout = d
+	lw.resetTimer()
+}
+
+// Write appends p to the buffered text and writes each complete line to the
+// underlying Writer. If the underlying Writer returns an error, the line is
+// discarded and the error is returned after the rest of p has been buffered.
+func (lw *LineWriter) Write(p []byte) (n int, err error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.closed {
+		return 0, ErrClosed
+	}
+
+	lw.buf = append(lw.buf, p...)
+	err = lw.writeLines()
+	lw.resetTimer()
+	return len(p), err
+}
+
+// WriteString appends s to the buffered text and writes each complete line
+// to the underlying Writer.
+func (lw *LineWriter) WriteString(s string) (n int, err error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.closed {
+		return 0, ErrClosed
+	}
+
+	lw.buf = append(lw.buf, s...)
+	err = lw.writeLines()
+	lw.resetTimer()
+	return len(s), err
+}
+
+// Flush writes any buffered partial line to the underlying Writer.
+func (lw *LineWriter) Flush() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	return lw.flush()
+}
+
+// Close flushes any buffered partial line and stops the idle timer. Writes
+// to the LineWriter after Close return ErrClosed. Close does not close the
+// underlying Writer.
+func (lw *LineWriter) Close() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.closed {
+		return nil
+	}
+
+	lw.closed = true
+	if nil != lw.timer {
+		lw.timer.Stop()
+		lw.timer = nil
+	}
+
+	return lw.flush()
+}
+
+// writeLines writes each complete line in the buffer to the underlying
+// Writer and keeps the trailing partial line in the buffer. A partial line
+// that reaches maxLineMessages times the limit of the current MessageSizer is
+// written as if it were complete. The first error that is returned by the
+// underlying Writer is returned.
+func (lw *LineWriter) writeLines() (err error) {
+	start := 0
+	for i, b := range lw.buf {
+		if '\n' != b {
+			continue
+		}
+
+		if e := lw.writeLine(lw.buf[start:i]); nil == err {
+			err = e
+		}
+
+		start = i + 1
+	}
+
+	lw.buf = append(lw.buf[:0], lw.buf[start:]...)
+
+	// A UTF-8 string is never shorter in bytes than in UTF-16 code units, so
+	// the length of the buffer is checked before the line is measured.
+	max := maxLineMessages * CurrentMessageSizer().MaxLength()
+	if len(lw.buf) >= max && UTF16Len(string(lw.buf)) >= max {
+		if e := lw.flush(); nil == err {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func (lw *LineWriter) flush() error {
+	if 0 == len(lw.buf) {
+		return nil
+	}
+
+	err := lw.writeLine(lw.buf)
+	lw.buf = lw.buf[:0]
+	return err
+}
+
+func (lw *LineWriter) writeLine(line []byte) error {
+	s := strings.TrimSuffix(string(line), "\r")
+	if "" == s {
+		return nil
+	}
+
+	_, err := lw.w.WriteString(s)
+	return err
+}
+
+// resetTimer restarts the idle timer if there is a partial line in the
+// buffer and stops it otherwise.
+func (lw *LineWriter) resetTimer() {
+	if 0 == len(lw.buf) || lw.idleTimeout <= 0 || lw.closed {
+		if nil != lw.timer {
+			lw.timer.Stop()
+		}
+
+		return
+	}
+
+	if nil == lw.timer {
+		lw.timer = time.AfterFunc(lw.idleTimeout, func() {
+			lw.Flush()
+		})
+		return
+	}
+
+	lw.timer.Reset(lw.idleTimeout)
+}
@@ -0,0 +1,152 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LineWriter", func() {
+	var recorder *recordingWriter
+	var writer *LineWriter
+
+	BeforeEach(func() {
+		recorder = &recordingWriter{}
+		writer = NewLineWriter(recorder)
+	})
+
+	AfterEach(func() {
+		writer.Close()
+	})
+
+	It("holds partial lines until a newline is written", func() {
+		fmt.Fprint(writer, "Hello, ")
+		Expect(recorder.Messages()).To(BeEmpty())
+
+		fmt.Fprint(writer, "World!\n")
+		Expect(recorder.Messages()).To(Equal([]string{"Hello, World!"}))
+	})
+
+	It("writes one message for each line", func() {
+		io.Copy(writer, strings.NewReader("one\r\ntwo\n\nthree\npartial"))
+		Expect(recorder.Messages()).To(Equal([]string{"one", "two", "three"}))
+	})
+
+	It("returns the number of bytes written", func() {
+		n, err := io.WriteString(writer, "one\ntwo")
+		Expect(n).To(Equal(7))
+		Expect(err).To(BeNil())
+	})
+
+	It("writes the partial line when flushed", func() {
+		io.WriteString(writer, "partial")
+		Expect(writer.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"partial"}))
+	})
+
+	It("writes the partial line when the idle timeout expires", func() {
+		writer.SetIdleTimeout(10 * time.Millisecond)
+		io.WriteString(writer, "partial")
+		Eventually(recorder.Messages).Should(Equal([]string{"partial"}))
+	})
+
+	Context("when a line is longer than the message size limit", func() {
+		var backend *mockProcessMonitor
+		var line string
+
+		BeforeEach(func() {
+			SetMessageSizer(MessageSizer{Limit: 20})
+			backend = &mockProcessMonitor{}
+			writer = NewLineWriter(NewWriter(backend))
+			line = strings.Repeat("0123456789", 5)
+		})
+
+		AfterEach(func() {
+			SetMessageSizer(MessageSizer{})
+		})
+
+		writeInPieces := func(s string) {
+			for i := 0; i < len(s); i += 10 {
+				io.WriteString(writer, s[i:i+10])
+			}
+		}
+
+		It("splits the line the same way however it is written", func() {
+			io.WriteString(writer, line+"\n")
+			whole := backend.messages
+			Expect(whole).To(HaveLen(4))
+			Expect(whole[0]).To(HavePrefix("(1/4) "))
+
+			backend.messages = nil
+			writeInPieces(line)
+			Expect(backend.messages).To(BeEmpty())
+			io.WriteString(writer, "\n")
+			Expect(backend.messages).To(Equal(whole))
+		})
+
+		It("truncates the line once when the policy truncates", func() {
+			SetMessageSizer(MessageSizer{Limit: 20, Policy: TruncateTail})
+			writeInPieces(line)
+			writer.Flush()
+			Expect(backend.messages).To(Equal([]string{line[:20]}))
+		})
+
+		It("writes a partial line that reaches the buffer limit", func() {
+			SetMessageSizer(MessageSizer{Limit: 20, Policy: TruncateTail})
+			writeInPieces(strings.Repeat("0123456789", 2*maxLineMessages))
+			Expect(backend.messages).To(Equal([]string{line[:20]}))
+
+			io.WriteString(writer, "next\n")
+			Expect(backend.messages).To(Equal([]string{line[:20], "next"}))
+		})
+	})
+
+	It("returns errors from the underlying writer", func() {
+		recorder.err = errors.New("failed")
+		_, err := io.WriteString(writer, "line\n")
+		Expect(err).To(MatchError("failed"))
+	})
+
+	Describe("Close", func() {
+		It("writes the partial line", func() {
+			io.WriteString(writer, "partial")
+			Expect(writer.Close()).To(Succeed())
+			Expect(recorder.Messages()).To(Equal([]string{"partial"}))
+		})
+
+		It("rejects later writes", func() {
+			writer.Close()
+			_, err := io.WriteString(writer, "line\n")
+			Expect(err).To(Equal(ErrClosed))
+		})
+	})
+})
@@ -0,0 +1,365 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Logger writes leveled and formatted debug messages to a Writer. Each
+// Logger can have its own threshold and severity prefix, or use the global
+// settings. The zero value is a Logger that writes to ProcessMonitor using
+// the global settings.
+//
+// Formatting is skipped when the level of a message is below the threshold
+// or when the Writer discards every debug message, for example when Process
+// Monitor is not running. Logger is safe for concurrent use.
+type Logger struct {
+	out       Writer
+	component string
+	traceID   string
+	parent    *Logger
+	own       loggerSettings
+	shared    *loggerSettings
+}
+
+// loggerSettings holds the settings of a Logger that can be changed while
+// the Logger is being used. Named loggers with the same component name
+// share their settings.
+type loggerSettings struct {
+	threshold    atomic.Int64
+	hasThreshold atomic.Bool
+	disabled     atomic.Bool
+	prefix       atomic.Value
+}
+
+// std is the Logger that is used by the package-level functions.
+var std = &Logger{}
+
+// New returns a Logger that writes debug messages to w. If w is nil, the
+// Logger writes to ProcessMonitor.
+func New(w Writer) *Logger {
+	return &Logger{out: w}
+}
+
+func (l *Logger) settings() *loggerSettings {
+	if nil != l.shared {
+		return l.shared
+	}
+
+	return &l.own
+}
+
+// Threshold returns the minimum level of the debug messages that are written
+// by the Logger. A named Logger without its own threshold uses the threshold
+// of the Logger that it was created from.
+func (l *Logger) Threshold() Level {
+	s := l.settings()
+	switch {
+	case s.hasThreshold.Load():
+		return Level(s.threshold.Load())
+	case nil != l.parent:
+		return l.parent.Threshold()
+	}
+
+	return Threshold()
+}
+
+// SetThreshold sets the minimum level of the debug messages that are written
+// by the Logger. The Logger no longer uses the global threshold.
+func (l *Logger) SetThreshold(level Level) {
+	s := l.settings()
+	s.threshold.Store(int64(level))
+	s.hasThreshold.Store(true)
+}
+
+// ResetThreshold makes the Logger use the global threshold, or the
+// threshold of the Logger that it was created from, again.
+func (l *Logger) ResetThreshold() {
+	l.settings().hasThreshold.Store(false)
+}
+
+// SetLevelPrefix sets the function that returns the severity prefix for the
+// debug messages that are written by the Logger's leveled methods. Passing
+// nil makes the Logger use the global prefix function again.
+func (l *Logger) SetLevelPrefix(f func(Level) string) {
+	l.settings().prefix.Store(f)
+}
+
+// Enabled returns true if a debug message with the specified level would be
+// written. Callers can use Enabled to avoid expensive work when preparing a
+// message that would be discarded.
+func (l *Logger) Enabled(level Level) bool {
+	return l.active() && level >= l.Threshold() && !discards(l.writer())
+}
+
+// SetEnabled turns the Logger on or off. Debug messages that are written to
+// a Logger that is turned off are discarded. Turning off a named Logger also
+// turns off the Loggers that were created from it.
+func (l *Logger) SetEnabled(enabled bool) {
+	l.settings().disabled.Store(!enabled)
+}
+
+// active returns true if the Logger and the Loggers that it was created from
+// are turned on.
+func (l *Logger) active() bool {
+	return !l.settings().disabled.Load() && (nil == l.parent || l.parent.active())
+}
+
+// WithTraceID returns a Logger that writes debug messages to the same Writer
+// as l, using the same settings, and marks each message with a trace ID. A
+// Sampler uses the trace ID to write all or none of the messages that belong
+// to the same request or operation.
+func (l *Logger) WithTraceID(id string) *Logger {
+	return &Logger{
+		out:       l.out,
+		component: l.component,
+		traceID:   id,
+		parent:    l.parent,
+		shared:    l.settings(),
+	}
+}
+
+// Component returns the component name of the Logger, or an empty string if
+// the Logger is not named.
+func (l *Logger) Component() string {
+	return l.component
+}
+
+// Prefix returns the text that the Logger adds to the beginning of a debug
+// message that is written with the specified level: the component name and
+// the severity prefix, such as "db: WARN: ". Adapters use Prefix to fit
+// their messages within the message size limit.
+func (l *Logger) Prefix(level Level) string {
+	prefix := l.levelPrefix()(level)
+	if "" != l.component {
+		prefix = l.component + ": " + prefix
+	}
+
+	return prefix
+}
+
+// Debugf formats and writes a debug message with LevelDebug.
+func (l *Logger) Debugf(format string, a ...interface{}) (n int, err error) {
+	return l.logf(LevelDebug, format, a...)
+}
+
+// Infof formats and writes a debug message with LevelInfo.
+func (l *Logger) Infof(format string, a ...interface{}) (n int, err error) {
+	return l.logf(LevelInfo, format, a...)
+}
+
+// Warnf formats and writes a debug message with LevelWarn.
+func (l *Logger) Warnf(format string, a ...interface{}) (n int, err error) {
+	return l.logf(LevelWarn, format, a...)
+}
+
+// Errorf formats and writes a debug message with LevelError.
+func (l *Logger) Errorf(format string, a ...interface{}) (n int, err error) {
+	return l.logf(LevelError, format, a...)
+}
+
+// Log writes a debug message with the specified level. Log is useful for
+// adapters that forward debug messages from other logging libraries.
+func (l *Logger) Log(level Level, message string) (n int, err error) {
+	if !l.Enabled(level) {
+		return 0, nil
+	}
+
+	return l.output(level, l.levelPrefix()(level)+message)
+}
+
+// Print formats a debug message using the default formats for the operands
+// and writes it without a severity prefix. Print ignores the threshold.
+func (l *Logger) Print(a ...interface{}) (n int, err error) {
+	if !l.active() || discards(l.writer()) {
+		return 0, nil
+	}
+
+	return l.output(LevelInfo, fmt.Sprint(a...))
+}
+
+// Println formats a debug message using the default formats for the operands
+// and writes it without a severity prefix. Spaces are always added between
+// the operands and the trailing newline is not written. Println ignores the
+// threshold.
+func (l *Logger) Println(a ...interface{}) (n int, err error) {
+	if !l.active() || discards(l.writer()) {
+		return 0, nil
+	}
+
+	return l.output(LevelInfo, strings.TrimSuffix(fmt.Sprintln(a...), "\n"))
+}
+
+// Printf formats a debug message using the format specifier and writes it
+// without a severity prefix. Printf ignores the threshold.
+func (l *Logger) Printf(format string, a ...interface{}) (n int, err error) {
+	if !l.active() || discards(l.writer()) {
+		return 0, nil
+	}
+
+	return l.output(LevelInfo, fmt.Sprintf(format, a...))
+}
+
+// Write writes p as a debug message without a severity prefix.
+func (l *Logger) Write(p []byte) (n int, err error) {
+	if !l.active() {
+		return len(p), nil
+	}
+
+	if _, err = l.output(LevelInfo, string(p)); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString writes s as a debug message without a severity prefix.
+func (l *Logger) WriteString(s string) (n int, err error) {
+	if !l.active() {
+		return len(s), nil
+	}
+
+	if _, err = l.output(LevelInfo, s); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// discards returns true if the Logger is turned off or its Writer discards
+// every debug message.
+func (l *Logger) discards() bool {
+	return !l.active() || discards(l.writer())
+}
+
+func (l *Logger) writer() Writer {
+	if nil != l.out {
+		return l.out
+	}
+
+	return outputWriter{}
+}
+
+func (l *Logger) levelPrefix() func(Level) string {
+	f, ok := l.settings().prefix.Load().(func(Level) string)
+	switch {
+	case ok && nil != f:
+		return f
+	case nil != l.parent:
+		return l.parent.levelPrefix()
+	}
+
+	return globalLevelPrefix()
+}
+
+func (l *Logger) logf(
+	level Level,
+	format string,
+	a ...interface{},
+) (n int, err error) {
+	if !l.Enabled(level) {
+		return 0, nil
+	}
+
+	return l.output(level, l.levelPrefix()(level)+fmt.Sprintf(format, a...))
+}
+
+func (l *Logger) output(level Level, message string) (n int, err error) {
+	if "" != l.component {
+		message = l.component + ": " + message
+	}
+
+	e := &Entry{
+		Time:      time.Now(),
+		Level:     level,
+		Component: l.component,
+		TraceID:   l.traceID,
+		Message:   message,
+	}
+	if err = WriteEntry(l.writer(), e); nil != err {
+		return 0, err
+	}
+
+	return len(message), nil
+}
+
+// Enabled returns true if a debug message with the specified level would be
+// written to ProcessMonitor by the leveled functions.
+func Enabled(level Level) bool {
+	return std.Enabled(level)
+}
+
+// Debugf formats a debug message and writes it to ProcessMonitor with
+// LevelDebug. It returns the number of characters that were written and any
+// error that occurred.
+func Debugf(format string, a ...interface{}) (n int, err error) {
+	return std.logf(LevelDebug, format, a...)
+}
+
+// Infof formats a debug message and writes it to ProcessMonitor with
+// LevelInfo. It returns the number of characters that were written and any
+// error that occurred.
+func Infof(format string, a ...interface{}) (n int, err error) {
+	return std.logf(LevelInfo, format, a...)
+}
+
+// Warnf formats a debug message and writes it to ProcessMonitor with
+// LevelWarn. It returns the number of characters that were written and any
+// error that occurred.
+func Warnf(format string, a ...interface{}) (n int, err error) {
+	return std.logf(LevelWarn, format, a...)
+}
+
+// Errorf formats a debug message and writes it to ProcessMonitor with
+// LevelError. It returns the number of characters that were written and any
+// error that occurred.
+func Errorf(format string, a ...interface{}) (n int, err error) {
+	return std.logf(LevelError, format, a...)
+}
+
+// Print formats a debug message using the default formats for the operands
+// and writes it to ProcessMonitor. It returns the number of characters that
+// were written and any error that occurred.
+func Print(a ...interface{}) (n int, err error) {
+	return std.Print(a...)
+}
+
+// Println formats a debug message using the default formats for the operands
+// and writes it to ProcessMonitor. It returns the number of characters that
+// were written and any error that occurred.
+func Println(a ...interface{}) (n int, err error) {
+	return std.Println(a...)
+}
+
+// Printf formats a debug message using the format specifier and writes it to
+// ProcessMonitor. It returns the number of characters that were written and
+// any error that occurred.
+func Printf(format string, a ...interface{}) (n int, err error) {
+	return std.Printf(format, a...)
+}
@@ -0,0 +1,201 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// entryRecorder is an EntryWriter that records every entry that is written
+// to it.
+type entryRecorder struct {
+	recordingWriter
+	entryMu sync.Mutex
+	entries []Entry
+}
+
+func (r *entryRecorder) WriteEntry(e *Entry) error {
+	r.entryMu.Lock()
+	r.entries = append(r.entries, *e)
+	r.entryMu.Unlock()
+
+	_, err := r.WriteString(e.Message)
+	return err
+}
+
+func (r *entryRecorder) Entries() []Entry {
+	r.entryMu.Lock()
+	defer r.entryMu.Unlock()
+
+	return append([]Entry(nil), r.entries...)
+}
+
+var _ = Describe("Logger", func() {
+	var recorder *entryRecorder
+	var logger *Logger
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+		logger = New(recorder)
+	})
+
+	Describe("leveled methods", func() {
+		It("add a severity prefix to the message", func() {
+			logger.Debugf("debug %d", 1)
+			logger.Infof("info %d", 2)
+			logger.Warnf("warn %d", 3)
+			logger.Errorf("error %d", 4)
+			Expect(recorder.Messages()).To(Equal([]string{
+				"DEBUG: debug 1",
+				"INFO: info 2",
+				"WARN: warn 3",
+				"ERROR: error 4",
+			}))
+		})
+
+		It("include Log, which takes the level as an argument", func() {
+			logger.SetThreshold(LevelInfo)
+			logger.Log(LevelDebug, "debug")
+			logger.Log(LevelWarn+1, "warn")
+			Expect(recorder.Messages()).To(Equal([]string{"WARN+1: warn"}))
+			Expect(recorder.Entries()[0].Level).To(Equal(LevelWarn + 1))
+		})
+
+		It("write entries with the level of the message", func() {
+			logger.Warnf("warning")
+			Expect(recorder.Entries()).To(HaveLen(1))
+			Expect(recorder.Entries()[0].Level).To(Equal(LevelWarn))
+			Expect(recorder.Entries()[0].Time.IsZero()).To(BeFalse())
+		})
+
+		It("do not write messages below the threshold", func() {
+			logger.SetThreshold(LevelWarn)
+			logger.Infof("info")
+			logger.Warnf("warn")
+			Expect(recorder.Messages()).To(Equal([]string{"WARN: warn"}))
+		})
+
+		It("do not format messages below the threshold", func() {
+			logger.SetThreshold(LevelError)
+			logger.Debugf("%v", formatSpy(func() {
+				Fail("the message was formatted")
+			}))
+		})
+
+		It("use the global threshold by default", func() {
+			defer SetThreshold(LevelDebug)
+			SetThreshold(LevelError)
+			logger.Warnf("warn")
+			Expect(recorder.Messages()).To(BeEmpty())
+
+			logger.SetThreshold(LevelDebug)
+			logger.Warnf("warn")
+			Expect(recorder.Messages()).To(HaveLen(1))
+
+			logger.ResetThreshold()
+			Expect(logger.Threshold()).To(Equal(LevelError))
+		})
+
+		It("use the configured severity prefix", func() {
+			logger.SetLevelPrefix(func(l Level) string {
+				return "[" + l.String() + "] "
+			})
+			logger.Errorf("failed")
+			Expect(recorder.Messages()).To(Equal([]string{"[ERROR] failed"}))
+		})
+
+		It("use the global severity prefix by default", func() {
+			defer SetLevelPrefix(nil)
+			SetLevelPrefix(func(Level) string { return "" })
+			logger.Errorf("failed")
+			Expect(recorder.Messages()).To(Equal([]string{"failed"}))
+		})
+	})
+
+	Describe("Print methods", func() {
+		It("write messages without a severity prefix", func() {
+			logger.Print("a", 1)
+			logger.Println("b", 2)
+			logger.Printf("c %d", 3)
+			Expect(recorder.Messages()).To(Equal([]string{"a1", "b 2", "c 3"}))
+		})
+
+		It("ignore the threshold", func() {
+			logger.SetThreshold(LevelError)
+			logger.Printf("message")
+			Expect(recorder.Messages()).To(HaveLen(1))
+		})
+	})
+
+	Describe("Enabled", func() {
+		It("returns false for levels below the threshold", func() {
+			logger.SetThreshold(LevelWarn)
+			Expect(logger.Enabled(LevelInfo)).To(BeFalse())
+			Expect(logger.Enabled(LevelWarn)).To(BeTrue())
+		})
+
+		It("returns false when the writer discards every message", func() {
+			logger = New(&nullProcessMonitor{})
+			Expect(logger.Enabled(LevelError)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("package-level logging functions", func() {
+	var original Writer
+	var recorder *entryRecorder
+
+	BeforeEach(func() {
+		original = Output()
+		recorder = &entryRecorder{}
+		SetOutput(recorder)
+	})
+
+	AfterEach(func() {
+		SetOutput(original)
+	})
+
+	It("write to ProcessMonitor", func() {
+		Infof("info")
+		Printf("print")
+		Expect(recorder.Messages()).To(Equal([]string{"INFO: info", "print"}))
+	})
+
+	It("are disabled when ProcessMonitor discards messages", func() {
+		SetOutput(&nullProcessMonitor{})
+		Expect(Enabled(LevelError)).To(BeFalse())
+	})
+})
+
+// formatSpy calls a function when it is formatted by the fmt package.
+type formatSpy func()
+
+func (f formatSpy) String() string {
+	f()
+	return ""
+}
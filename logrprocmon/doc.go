@@ -0,0 +1,45 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+/*
+Package logrprocmon provides a logr.LogSink that writes log entries to
+Process Monitor.
+
+	logger := logr.New(logrprocmon.NewSink(nil)).WithName("reconciler")
+	logger.V(1).Info("synced", "pod", name, "took", took)
+
+Each entry is written as a single debug message with a severity prefix,
+followed by the key/value pairs from WithValues and then those of the call.
+A key without a value is written as "<no-value>", and values that implement
+logr.Marshaler are written using MarshalLog. The error that is passed to
+Error is written after the key/value pairs:
+
+	reconciler: DEBUG: synced pod=web-0 took=12ms
+	reconciler: ERROR: sync failed pod=web-1 error="not found"
+
+V-levels are mapped to procmon levels by Level, and logger names are used as
+procmon component names, so the verbosity of a named logger can be changed
+using procmon.Named.
+*/
+package logrprocmon
@@ -0,0 +1,177 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package logrprocmon
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+
+	"github.com/go-logr/logr"
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+)
+
+// noValue is written for a key that does not have a value, matching the
+// placeholder that is used by the funcr package of logr.
+const noValue = "<no-value>"
+
+// SinkOptions configures a Sink.
+type SinkOptions struct {
+	// Logger writes the entries. The V-level of an entry is converted by
+	// Level and compared with the threshold of Logger, and the names that
+	// WithName adds are appended to the component name of Logger. If
+	// Logger is nil, entries are written to procmon.ProcessMonitor.
+	Logger *procmon.Logger
+
+	// AddCaller adds the source location of the code that wrote an entry.
+	// Helper functions that use logr.Logger.WithCallDepth or
+	// logr.Logger.WithCallStackHelper are skipped.
+	AddCaller bool
+}
+
+// Sink is a logr.LogSink that writes log entries to Process Monitor. Sink
+// is safe for concurrent use.
+type Sink struct {
+	opts   SinkOptions
+	values []interface{}
+	depth  int
+}
+
+var _ logr.CallDepthLogSink = (*Sink)(nil)
+
+// NewSink returns a Sink that uses opts. If opts is nil, the default options
+// are used.
+func NewSink(opts *SinkOptions) *Sink {
+	s := &Sink{}
+	if nil != opts {
+		s.opts = *opts
+	}
+
+	if nil == s.opts.Logger {
+		s.opts.Logger = new(procmon.Logger)
+	}
+
+	return s
+}
+
+// Init records the number of stack frames between the code that wrote an
+// entry and the Sink.
+func (s *Sink) Init(info logr.RuntimeInfo) {
+	s.depth += info.CallDepth
+}
+
+// Enabled returns true if entries with the V-level would be written.
+// Enabled returns false if the Logger discards every debug message, for
+// example when Process Monitor is not running.
+func (s *Sink) Enabled(level int) bool {
+	return s.opts.Logger.Enabled(Level(level))
+}
+
+// Info writes a log entry with the procmon level of the V-level.
+func (s *Sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.write(Level(level), msg, nil, keysAndValues)
+}
+
+// Error writes a log entry with procmon.LevelError. The error is written
+// after the key/value pairs.
+func (s *Sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if s.opts.Logger.Enabled(procmon.LevelError) {
+		s.write(procmon.LevelError, msg, err, keysAndValues)
+	}
+}
+
+// WithValues returns a Sink that adds the key/value pairs to each entry.
+func (s *Sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.values = make([]interface{}, 0, len(s.values)+len(keysAndValues))
+	clone.values = append(append(clone.values, s.values...), keysAndValues...)
+	return &clone
+}
+
+// WithName returns a Sink for the procmon component that is named after
+// the logger. The name is added to the component name of the Logger.
+func (s *Sink) WithName(name string) logr.LogSink {
+	clone := *s
+	clone.opts.Logger = s.opts.Logger.Named(name)
+	return &clone
+}
+
+// WithCallDepth returns a Sink that skips depth more stack frames when
+// finding the caller.
+func (s *Sink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.depth += depth
+	return &clone
+}
+
+func (s *Sink) write(level procmon.Level, msg string, err error, keysAndValues []interface{}) {
+	var b bytes.Buffer
+	b.WriteString(msg)
+	appendValues(&b, s.values)
+	appendValues(&b, keysAndValues)
+	logfmt.AppendError(&b, err)
+	if s.opts.AddCaller {
+		// The depth covers the logr.Logger method and its callers' helpers.
+		// Skip write and Info or Error too.
+		if _, file, line, ok := runtime.Caller(s.depth + 2); ok {
+			logfmt.AppendCaller(&b, file, line)
+		}
+	}
+
+	s.opts.Logger.Log(level, b.String())
+}
+
+func appendValues(b *bytes.Buffer, keysAndValues []interface{}) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		v := interface{}(noValue)
+		if i+1 < len(keysAndValues) {
+			v = keysAndValues[i+1]
+		}
+
+		if m, ok := v.(logr.Marshaler); ok {
+			v = m.MarshalLog()
+		}
+
+		logfmt.Append(b, key, logfmt.Value(v))
+	}
+}
+
+// Level returns the procmon level that a V-level is written with. V(0) is
+// written as procmon.LevelInfo, V(1) as procmon.LevelDebug, and each
+// higher V-level one procmon level below the previous one, so V(3) is
+// written as procmon.LevelDebug-2.
+func Level(v int) procmon.Level {
+	if 0 >= v {
+		return procmon.LevelInfo
+	}
+
+	return procmon.LevelDebug - procmon.Level(v-1)
+}
@@ -0,0 +1,154 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package logrprocmon
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/go-logr/logr"
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sink", func() {
+	var recorder *procmontest.Recorder
+	var opts *SinkOptions
+	var logger logr.Logger
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		opts = &SinkOptions{
+			Logger: procmon.New(procmon.NewWriter(recorder)),
+		}
+		logger = logr.New(NewSink(opts))
+	})
+
+	It("writes V-levels with the procmon level from Level", func() {
+		opts.Logger.SetThreshold(procmon.LevelDebug - 1)
+		logger.Info("watching")
+		logger.V(1).Info("synced")
+		logger.V(2).Info("diffed")
+		logger.V(3).Info("hidden")
+		Expect(recorder.Texts()).To(Equal([]string{
+			"INFO: watching",
+			"DEBUG: synced",
+			"DEBUG-1: diffed",
+		}))
+	})
+
+	It("writes key/value pairs in order", func() {
+		logger.Info("synced",
+			"pod", "web-0",
+			"took", "12ms",
+			"labels", map[string]string{"app": "web"},
+			7, "replicas",
+			"dangling")
+		Expect(recorder.Texts()).To(Equal([]string{
+			`INFO: synced pod=web-0 took=12ms labels=map[app:web] 7=replicas dangling=<no-value>`,
+		}))
+	})
+
+	It("adds values from WithValues", func() {
+		logger.WithValues("namespace", "prod").Info("synced", "pod", "web-0")
+		Expect(recorder.Texts()).To(Equal([]string{
+			"INFO: synced namespace=prod pod=web-0",
+		}))
+	})
+
+	It("uses MarshalLog", func() {
+		logger.Info("pulled", "credentials", secret("hunter2"))
+		Expect(recorder.Texts()).To(Equal([]string{"INFO: pulled credentials=REDACTED"}))
+	})
+
+	It("writes the error of Error after the values", func() {
+		logger.Error(errors.New("not found"), "sync failed", "pod", "web-1")
+		Expect(recorder.Texts()).To(Equal([]string{
+			`ERROR: sync failed pod=web-1 error="not found"`,
+		}))
+	})
+
+	It("writes the caller last", func() {
+		opts.AddCaller = true
+		logger = logr.New(NewSink(opts))
+		logger.Error(errors.New("not found"), "sync failed")
+		logger.WithCallDepth(0).Info("requeued")
+		Expect(recorder.Texts()).To(HaveLen(2))
+		Expect(recorder.Texts()[0]).To(MatchRegexp(
+			`^ERROR: sync failed error="not found" caller=logrprocmon/sink_test\.go:\d+$`))
+		Expect(recorder.Texts()[1]).To(MatchRegexp(
+			`^INFO: requeued caller=logrprocmon/sink_test\.go:\d+$`))
+	})
+
+	It("skips helper frames", func() {
+		opts.AddCaller = true
+		logger = logr.New(NewSink(opts))
+		helper := func(l logr.Logger) {
+			l.WithCallDepth(1).Info("from helper")
+		}
+
+		_, _, line, _ := runtime.Caller(0)
+		helper(logger)
+		Expect(recorder.Texts()).To(Equal([]string{
+			fmt.Sprintf("INFO: from helper caller=logrprocmon/sink_test.go:%d", line+1),
+		}))
+	})
+
+	It("uses logger names as component names", func() {
+		named := logger.WithName("logrprocmon-test").WithName("reconciler")
+		named.V(1).Info("synced")
+		procmon.Named("logrprocmon-test.reconciler").SetThreshold(procmon.LevelInfo)
+		defer procmon.Named("logrprocmon-test.reconciler").ResetThreshold()
+
+		named.V(1).Info("synced again")
+		Expect(named.V(1).Enabled()).To(BeFalse())
+		Expect(recorder.Texts()).To(Equal([]string{
+			"logrprocmon-test.reconciler: DEBUG: synced",
+		}))
+	})
+
+	It("is not enabled when the output discards debug messages", func() {
+		defer procmon.SetOutput(nil)()
+		Expect(logr.New(NewSink(nil)).Enabled()).To(BeFalse())
+	})
+})
+
+var _ = Describe("Level", func() {
+	It("writes each V-level above 1 one procmon level below the previous one", func() {
+		Expect(Level(0)).To(Equal(procmon.LevelInfo))
+		Expect(Level(1)).To(Equal(procmon.LevelDebug))
+		Expect(Level(3)).To(Equal(procmon.LevelDebug - 2))
+	})
+})
+
+// secret is a logr.Marshaler that hides its value.
+type secret string
+
+func (secret) MarshalLog() interface{} {
+	return "REDACTED"
+}
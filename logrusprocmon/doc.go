@@ -0,0 +1,40 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+/*
+Package logrusprocmon provides a logrus Hook that mirrors log entries to
+Process Monitor.
+
+	logrus.AddHook(logrusprocmon.NewHook(nil))
+	logrus.WithFields(logrus.Fields{"src": src, "dst": dst}).
+		WithError(err).Warn("copy failed")
+
+Each entry is written as a single debug message with a severity prefix.
+logrus keeps the fields of an entry in a map, so they are written in logfmt
+format sorted by key. The field that WithError adds is written after the
+other fields, followed by the caller if SetReportCaller is enabled:
+
+	WARN: copy failed dst=backup.zip src=data.zip error="disk full"
+*/
+package logrusprocmon
@@ -0,0 +1,143 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package logrusprocmon
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+	"github.com/sirupsen/logrus"
+)
+
+// HookOptions configures a Hook.
+type HookOptions struct {
+	// Logger writes the entries. logrus only fires the Hook for entries at
+	// or above the level of the logrus logger, and the threshold of Logger
+	// is applied after that. The component name of Logger is written before
+	// each entry. If Logger is nil, entries are written to
+	// procmon.ProcessMonitor.
+	Logger *procmon.Logger
+
+	// Levels are the logrus levels that the Hook is fired for. If Levels is
+	// nil, the Hook is fired for every level and the threshold of Logger
+	// decides which entries are written.
+	Levels []logrus.Level
+}
+
+// Hook is a logrus.Hook that writes log entries to Process Monitor. Hook is
+// safe for concurrent use.
+type Hook struct {
+	opts HookOptions
+}
+
+// NewHook returns a Hook that uses opts. If opts is nil, the default options
+// are used.
+func NewHook(opts *HookOptions) *Hook {
+	h := &Hook{}
+	if nil != opts {
+		h.opts = *opts
+	}
+
+	if nil == h.opts.Logger {
+		h.opts.Logger = new(procmon.Logger)
+	}
+
+	if nil == h.opts.Levels {
+		h.opts.Levels = logrus.AllLevels
+	}
+
+	return h
+}
+
+// Levels returns the logrus levels of the entries that the Hook writes.
+func (h *Hook) Levels() []logrus.Level {
+	return h.opts.Levels
+}
+
+// Fire writes the entry as a single debug message.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	level := Level(entry.Level)
+	if !h.opts.Logger.Enabled(level) {
+		return nil
+	}
+
+	var b bytes.Buffer
+	b.WriteString(strings.TrimSuffix(entry.Message, "\n"))
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		if logrus.ErrorKey != key {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		logfmt.Append(&b, key, logfmt.Value(entry.Data[key]))
+	}
+
+	if v, ok := entry.Data[logrus.ErrorKey]; ok {
+		logfmt.Append(&b, logfmt.ErrorKey, logfmt.Value(v))
+	}
+
+	if entry.HasCaller() {
+		logfmt.AppendCaller(&b, entry.Caller.File, entry.Caller.Line)
+	}
+
+	_, err := h.opts.Logger.Log(level, b.String())
+	return err
+}
+
+// Level returns the procmon level that a logrus level is written with.
+// logrus.TraceLevel is written as procmon.LevelDebug-4,
+// logrus.PanicLevel as procmon.LevelError+4, and logrus.FatalLevel as
+// procmon.LevelError+8. The other logrus levels are written with the
+// procmon level of the same name. Levels that logrus does not define are
+// written as trace entries.
+func Level(l logrus.Level) procmon.Level {
+	switch l {
+	case logrus.PanicLevel:
+		return logfmt.LevelPanic
+	case logrus.FatalLevel:
+		return logfmt.LevelFatal
+	case logrus.ErrorLevel:
+		return procmon.LevelError
+	case logrus.WarnLevel:
+		return procmon.LevelWarn
+	case logrus.InfoLevel:
+		return procmon.LevelInfo
+	case logrus.DebugLevel:
+		return procmon.LevelDebug
+	case logrus.TraceLevel:
+		return logfmt.LevelTrace
+	default:
+		// logrus levels are more verbose as their values grow, so an
+		// undefined level is more verbose than logrus.TraceLevel.
+		return logfmt.LevelTrace
+	}
+}
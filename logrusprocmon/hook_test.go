@@ -0,0 +1,119 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package logrusprocmon
+
+import (
+	"errors"
+	"io"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Hook", func() {
+	var recorder *procmontest.Recorder
+	var opts *HookOptions
+	var logger *logrus.Logger
+
+	newLogger := func() *logrus.Logger {
+		l := logrus.New()
+		l.SetOutput(io.Discard)
+		l.SetLevel(logrus.TraceLevel)
+		l.AddHook(NewHook(opts))
+		return l
+	}
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		opts = &HookOptions{
+			Logger: procmon.New(procmon.NewWriter(recorder)),
+		}
+		logger = newLogger()
+	})
+
+	It("writes the entry before logrus panics", func() {
+		Expect(func() { logger.WithField("job", 7).Panic("aborted") }).To(Panic())
+		Expect(recorder.Texts()).To(Equal([]string{"ERROR+4: aborted job=7"}))
+	})
+
+	It("sorts the fields by key", func() {
+		logger.WithFields(logrus.Fields{
+			"src":   `C:\Users\jane\My Documents\data.zip`,
+			"dst":   "backup.zip",
+			"bytes": 1 << 20,
+		}).Info("copied")
+		Expect(recorder.Texts()).To(Equal([]string{
+			`INFO: copied bytes=1048576 dst=backup.zip src="C:\\Users\\jane\\My Documents\\data.zip"`,
+		}))
+	})
+
+	It("writes the field that WithError adds after the other fields", func() {
+		logger.WithError(errors.New("disk full")).
+			WithField("dst", "backup.zip").
+			Warn("copy failed")
+		Expect(recorder.Texts()).To(Equal([]string{
+			`WARN: copy failed dst=backup.zip error="disk full"`,
+		}))
+	})
+
+	It("writes the caller last when logrus reports callers", func() {
+		logger.SetReportCaller(true)
+		logger.WithError(errors.New("disk full")).Warn("copy failed")
+		Expect(recorder.Texts()).To(HaveLen(1))
+		Expect(recorder.Texts()[0]).To(MatchRegexp(
+			`^WARN: copy failed error="disk full" caller=logrusprocmon/hook_test\.go:\d+$`))
+	})
+
+	It("trims the newline that Println-style methods add", func() {
+		logger.Infoln("copying", 3, "files")
+		Expect(recorder.Texts()).To(Equal([]string{"INFO: copying 3 files"}))
+	})
+
+	It("is fired for the configured levels", func() {
+		opts.Levels = []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel}
+		logger = newLogger()
+		logger.Info("info")
+		logger.Warn("warn")
+		Expect(recorder.Texts()).To(Equal([]string{"WARN: warn"}))
+		Expect(NewHook(nil).Levels()).To(Equal(logrus.AllLevels))
+	})
+})
+
+var _ = Describe("Level", func() {
+	It("maps the levels that procmon does not name", func() {
+		Expect(Level(logrus.TraceLevel)).To(Equal(logfmt.LevelTrace))
+		Expect(Level(logrus.PanicLevel)).To(Equal(logfmt.LevelPanic))
+		Expect(Level(logrus.FatalLevel)).To(Equal(logfmt.LevelFatal))
+		Expect(Level(logrus.WarnLevel)).To(Equal(procmon.LevelWarn))
+	})
+
+	It("writes undefined levels as trace entries", func() {
+		Expect(Level(logrus.TraceLevel + 1)).To(Equal(logfmt.LevelTrace))
+	})
+})
@@ -0,0 +1,271 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the maximum length of a debug message that Process
+// Monitor will display correctly in its log. The length is measured in UTF-16
+// code units because Process Monitor receives debug messages as UTF-16
+// strings.
+const MaxMessageLength = 2048
+
+// minMessageLength is the smallest size limit that a MessageSizer will use.
+// Smaller limits would not leave room for a continuation marker and at least
+// one surrogate pair.
+const minMessageLength = 16
+
+// ellipsis replaces the characters that are removed from the middle of a
+// message by the MiddleEllipsis policy.
+const ellipsis = "..."
+
+// OversizePolicy determines what happens to a debug message that is longer
+// than the message size limit.
+type OversizePolicy int
+
+const (
+	// SplitMessage splits an oversized message into multiple debug messages.
+	// Each chunk is prefixed with a continuation marker such as "(2/5) " so
+	// that the chunks can be joined back together when reading the log.
+	SplitMessage OversizePolicy = iota
+
+	// TruncateHead removes characters from the beginning of an oversized
+	// message and keeps the end of the message.
+	TruncateHead
+
+	// TruncateTail removes characters from the end of an oversized message
+	// and keeps the beginning of the message.
+	TruncateTail
+
+	// MiddleEllipsis removes characters from the middle of an oversized
+	// message and replaces them with "...", keeping both the beginning and
+	// the end of the message.
+	MiddleEllipsis
+
+	// RejectMessage does not write an oversized message. The Process Monitor
+	// writer returns ErrMessageTooLong instead.
+	RejectMessage
+)
+
+// String returns the name of the oversize policy.
+func (p OversizePolicy) String() string {
+	switch p {
+	case SplitMessage:
+		return "split"
+	case TruncateHead:
+		return "truncate-head"
+	case TruncateTail:
+		return "truncate-tail"
+	case MiddleEllipsis:
+		return "middle-ellipsis"
+	case RejectMessage:
+		return "reject"
+	}
+
+	return "OversizePolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// MessageSizer fits debug messages into the size limit of a Process Monitor
+// debug event. The zero value splits messages that are longer than
+// MaxMessageLength.
+type MessageSizer struct {
+	// Limit is the maximum length of a debug message in UTF-16 code units.
+	// If Limit is zero, MaxMessageLength is used. Limits smaller than 16 are
+	// raised to 16.
+	Limit int
+
+	// Policy determines what is done with messages that are longer than
+	// Limit.
+	Policy OversizePolicy
+}
+
+var sizer atomic.Value
+
+func init() {
+	sizer.Store(MessageSizer{})
+}
+
+// SetMessageSizer changes how the Process Monitor writer handles debug
+// messages that are longer than the size limit. SetMessageSizer is safe to
+// call while other goroutines are writing debug messages.
+func SetMessageSizer(s MessageSizer) {
+	sizer.Store(s)
+}
+
+// CurrentMessageSizer returns the MessageSizer that is used by the Process
+// Monitor writer.
+func CurrentMessageSizer() MessageSizer {
+	return sizer.Load().(MessageSizer)
+}
+
+// Fit returns the debug messages that should be written in place of message.
+// Messages that fit within the size limit are returned unchanged. Oversized
+// messages are split or truncated according to the policy. Messages are
+// never cut inside of a UTF-16 surrogate pair. Fit returns nil if message is
+// oversized and the policy is RejectMessage.
+func (s MessageSizer) Fit(message string) []string {
+	limit := s.MaxLength()
+	if UTF16Len(message) <= limit {
+		return []string{message}
+	}
+
+	switch s.Policy {
+	case RejectMessage:
+		return nil
+	case TruncateHead:
+		return []string{keepTail(message, limit)}
+	case TruncateTail:
+		return []string{keepHead(message, limit)}
+	case MiddleEllipsis:
+		head := (limit - len(ellipsis) + 1) / 2
+		tail := limit - len(ellipsis) - head
+		return []string{
+			keepHead(message, head) + ellipsis + keepTail(message, tail),
+		}
+	}
+
+	return split(message, limit)
+}
+
+// MaxLength returns the length in UTF-16 code units that debug messages are
+// fitted to. MaxLength is Limit, or MaxMessageLength if Limit is zero, and
+// is never less than 16.
+func (s MessageSizer) MaxLength() int {
+	switch {
+	case 0 == s.Limit:
+		return MaxMessageLength
+	case s.Limit < minMessageLength:
+		return minMessageLength
+	}
+
+	return s.Limit
+}
+
+// split breaks message into chunks that each fit within limit after a
+// continuation marker is added. The width of the marker depends on the number
+// of chunks, so the message is split again if the number of chunks needs
+// more digits than were reserved.
+func split(message string, limit int) []string {
+	var chunks []string
+	for digits := 1; ; digits++ {
+		chunks = chunk(message, limit-markerLen(digits))
+		if len(strconv.Itoa(len(chunks))) <= digits {
+			break
+		}
+	}
+
+	for i := range chunks {
+		chunks[i] = continuationMarker(i, len(chunks)) + chunks[i]
+	}
+
+	return chunks
+}
+
+// continuationMarker returns the marker that is added to the beginning of
+// chunk i of a message that was split into n chunks.
+func continuationMarker(i, n int) string {
+	return "(" + strconv.Itoa(i+1) + "/" + strconv.Itoa(n) + ") "
+}
+
+// markerLen returns the maximum length of a continuation marker when the
+// chunk count has the specified number of digits.
+func markerLen(digits int) int {
+	return len("(/) ") + 2*digits
+}
+
+// chunk breaks message into pieces that are no longer than width UTF-16 code
+// units.
+func chunk(message string, width int) []string {
+	var chunks []string
+	start, units := 0, 0
+	for i, r := range message {
+		n := runeLen(r)
+		if units+n > width {
+			chunks = append(chunks, message[start:i])
+			start, units = i, 0
+		}
+
+		units += n
+	}
+
+	return append(chunks, message[start:])
+}
+
+// keepHead returns the longest prefix of message that is no longer than width
+// UTF-16 code units.
+func keepHead(message string, width int) string {
+	units := 0
+	for i, r := range message {
+		units += runeLen(r)
+		if units > width {
+			return message[:i]
+		}
+	}
+
+	return message
+}
+
+// keepTail returns the longest suffix of message that is no longer than width
+// UTF-16 code units.
+func keepTail(message string, width int) string {
+	units := 0
+	for i := len(message); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(message[:i])
+		units += runeLen(r)
+		if units > width {
+			return message[i:]
+		}
+
+		i -= size
+	}
+
+	return message
+}
+
+// UTF16Len returns the number of UTF-16 code units that are needed to encode
+// s, which is how the length of a debug message is measured. Invalid UTF-8
+// bytes are counted as U+FFFD.
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen(r)
+	}
+
+	return n
+}
+
+// runeLen returns the number of UTF-16 code units that are needed to encode
+// r.
+func runeLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,149 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// joinChunks removes the continuation markers from split messages and joins
+// the chunks back together.
+func joinChunks(chunks []string) string {
+	var b strings.Builder
+	for _, c := range chunks {
+		b.WriteString(c[strings.Index(c, ") ")+2:])
+	}
+
+	return b.String()
+}
+
+var _ = Describe("MessageSizer", func() {
+	Describe("Fit", func() {
+		It("does not change messages that fit within the limit", func() {
+			sizer := MessageSizer{Limit: 16}
+			Expect(sizer.Fit("Hello, World!")).To(Equal([]string{"Hello, World!"}))
+		})
+
+		It("uses MaxMessageLength when the limit is not set", func() {
+			message := strings.Repeat("x", MaxMessageLength)
+			Expect(MessageSizer{}.Fit(message)).To(HaveLen(1))
+			Expect(MessageSizer{}.Fit(message + "x")).To(HaveLen(2))
+		})
+
+		It("counts the limit in UTF-16 code units", func() {
+			message := strings.Repeat("é", 16)
+			Expect(MessageSizer{Limit: 16}.Fit(message)).To(HaveLen(1))
+		})
+
+		Context("when the policy is SplitMessage", func() {
+			var message string
+			var chunks []string
+
+			BeforeEach(func() {
+				message = strings.Repeat("0123456789", 10)
+				chunks = MessageSizer{Limit: 20}.Fit(message)
+			})
+
+			It("prefixes each chunk with a continuation marker", func() {
+				Expect(chunks[0]).To(HavePrefix("(1/"))
+				Expect(chunks[len(chunks)-1]).To(HavePrefix("(8/8) "))
+			})
+
+			It("keeps every chunk within the limit", func() {
+				for _, c := range chunks {
+					Expect(UTF16Len(c)).To(BeNumerically("<=", 20))
+				}
+			})
+
+			It("does not lose any characters", func() {
+				Expect(joinChunks(chunks)).To(Equal(message))
+			})
+
+			It("reserves room for multi-digit chunk counts", func() {
+				chunks = MessageSizer{Limit: 16}.Fit(strings.Repeat("x", 100))
+				Expect(len(chunks)).To(BeNumerically(">=", 10))
+				for _, c := range chunks {
+					Expect(UTF16Len(c)).To(BeNumerically("<=", 16))
+				}
+			})
+
+			It("does not split surrogate pairs", func() {
+				message = strings.Repeat("😀", 20)
+				chunks = MessageSizer{Limit: 17}.Fit(message)
+				for _, c := range chunks {
+					Expect(UTF16Len(c)).To(BeNumerically("<=", 17))
+					Expect(strings.ToValidUTF8(c, "?")).To(Equal(c))
+				}
+
+				Expect(joinChunks(chunks)).To(Equal(message))
+			})
+		})
+
+		Context("when the policy is TruncateHead", func() {
+			It("keeps the end of the message", func() {
+				sizer := MessageSizer{Limit: 16, Policy: TruncateHead}
+				Expect(sizer.Fit("abcdefghijklmnopqrstuvwxyz")).
+					To(Equal([]string{"klmnopqrstuvwxyz"}))
+			})
+		})
+
+		Context("when the policy is TruncateTail", func() {
+			It("keeps the beginning of the message", func() {
+				sizer := MessageSizer{Limit: 16, Policy: TruncateTail}
+				Expect(sizer.Fit("abcdefghijklmnopqrstuvwxyz")).
+					To(Equal([]string{"abcdefghijklmnop"}))
+			})
+
+			It("does not split surrogate pairs", func() {
+				sizer := MessageSizer{Limit: 17, Policy: TruncateTail}
+				Expect(sizer.Fit(strings.Repeat("😀", 10))).
+					To(Equal([]string{strings.Repeat("😀", 8)}))
+			})
+		})
+
+		Context("when the policy is MiddleEllipsis", func() {
+			It("keeps the beginning and end of the message", func() {
+				sizer := MessageSizer{Limit: 16, Policy: MiddleEllipsis}
+				Expect(sizer.Fit("abcdefghijklmnopqrstuvwxyz")).
+					To(Equal([]string{"abcdefg...uvwxyz"}))
+			})
+		})
+	})
+})
+
+var _ = Describe("SetMessageSizer", func() {
+	AfterEach(func() {
+		SetMessageSizer(MessageSizer{})
+	})
+
+	It("changes the current message sizer", func() {
+		SetMessageSizer(MessageSizer{Limit: 100, Policy: TruncateTail})
+		Expect(CurrentMessageSizer()).
+			To(Equal(MessageSizer{Limit: 100, Policy: TruncateTail}))
+	})
+})
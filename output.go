@@ -0,0 +1,143 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import "sync/atomic"
+
+// output holds the Writer that ProcessMonitor and the package-level
+// functions write to. A nil pointer means that debug messages are discarded.
+var output atomic.Pointer[Writer]
+
+// discardOutput is returned by Output when debug messages are discarded.
+var discardOutput Writer = &nullProcessMonitor{}
+
+// Output returns the Writer that ProcessMonitor and the package-level
+// functions write debug messages to.
+func Output() Writer {
+	if w := output.Load(); nil != w {
+		return *w
+	}
+
+	return discardOutput
+}
+
+// SetOutput changes the Writer that ProcessMonitor and the package-level
+// functions write debug messages to. If w is nil, debug messages are
+// discarded. SetOutput is safe to call while other goroutines are writing
+// debug messages. SetOutput returns a function that restores the previous
+// Writer, which is useful in tests:
+//
+//	restore := procmon.SetOutput(recorder)
+//	defer restore()
+func SetOutput(w Writer) (restore func()) {
+	var previous *Writer
+	if nil == w {
+		previous = output.Swap(nil)
+	} else {
+		previous = output.Swap(&w)
+	}
+
+	return func() {
+		output.Store(previous)
+	}
+}
+
+// swapOutput makes w the output if debug messages are being discarded or the
+// output is still the connection from, which may be nil. swapOutput returns
+// false and leaves the output alone if another Writer was installed using
+// SetOutput, so that Open and Close do not detach it.
+func swapOutput(from *connection, w Writer) bool {
+	var next *Writer
+	if nil != w {
+		next = &w
+	}
+
+	for {
+		p := output.Load()
+		if nil != p && discardOutput != *p && (nil == from || Writer(from) != *p) {
+			return false
+		}
+
+		if output.CompareAndSwap(p, next) {
+			return true
+		}
+	}
+}
+
+// outputWriter is the Writer that is assigned to ProcessMonitor. It forwards
+// every debug message to the current FlightRecorder and output. When the
+// output is the connection that was opened by Open, the sequence number of
+// the recorded message is passed along so that a message that has already
+// been replayed is not written twice.
+type outputWriter struct{}
+
+func (outputWriter) Write(p []byte) (n int, err error) {
+	out := Output()
+	if fr := CurrentFlightRecorder(); nil != fr {
+		seq := fr.record(newEntry(string(p)))
+		if c, ok := out.(*connection); ok {
+			return c.writeRecorded(fr, seq, len(p), func(w *processMonitorWriter) (int, error) {
+				return w.Write(p)
+			})
+		}
+	}
+
+	return out.Write(p)
+}
+
+func (outputWriter) WriteString(s string) (n int, err error) {
+	out := Output()
+	if fr := CurrentFlightRecorder(); nil != fr {
+		seq := fr.record(newEntry(s))
+		if c, ok := out.(*connection); ok {
+			return c.writeRecorded(fr, seq, len(s), func(w *processMonitorWriter) (int, error) {
+				return w.WriteString(s)
+			})
+		}
+	}
+
+	return out.WriteString(s)
+}
+
+func (outputWriter) WriteEntry(e *Entry) error {
+	out := Output()
+	if fr := CurrentFlightRecorder(); nil != fr {
+		seq := fr.record(e)
+		if c, ok := out.(*connection); ok {
+			_, err := c.writeRecorded(fr, seq, len(e.Message), func(w *processMonitorWriter) (int, error) {
+				return w.WriteString(e.Message)
+			})
+			return err
+		}
+	}
+
+	return WriteEntry(out, e)
+}
+
+// discards returns true if the output discards debug messages and there is
+// no FlightRecorder to keep them.
+func (outputWriter) discards() bool {
+	return nil == CurrentFlightRecorder() && discards(Output())
+}
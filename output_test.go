@@ -0,0 +1,134 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"io"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SetOutput", func() {
+	var original Writer
+
+	BeforeEach(func() {
+		original = Output()
+	})
+
+	AfterEach(func() {
+		SetOutput(original)
+	})
+
+	It("changes where ProcessMonitor writes debug messages", func() {
+		recorder := &recordingWriter{}
+		SetOutput(recorder)
+		Expect(Output()).To(Equal(recorder))
+
+		io.WriteString(ProcessMonitor, "Hello, World!")
+		Infof("leveled")
+		Expect(recorder.Messages()).To(Equal([]string{"Hello, World!", "INFO: leveled"}))
+	})
+
+	It("returns a function that restores the previous output", func() {
+		first := &recordingWriter{}
+		second := &recordingWriter{}
+		SetOutput(first)
+
+		restore := SetOutput(second)
+		Expect(Output()).To(Equal(second))
+		restore()
+		Expect(Output()).To(Equal(first))
+	})
+
+	It("discards debug messages when the output is nil", func() {
+		SetOutput(nil)
+		Expect(discards(ProcessMonitor)).To(BeTrue())
+		Expect(Enabled(LevelError)).To(BeFalse())
+
+		n, err := io.WriteString(ProcessMonitor, "discarded")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(9))
+	})
+
+	It("forwards entries written to ProcessMonitor", func() {
+		recorder := &entryRecorder{}
+		SetOutput(recorder)
+		Expect(WriteEntry(ProcessMonitor, &Entry{Component: "db", Message: "db: hi"})).To(Succeed())
+		Expect(recorder.Entries()).To(HaveLen(1))
+		Expect(recorder.Entries()[0].Component).To(Equal("db"))
+	})
+
+	// These specs are most useful when the tests are run with the race
+	// detector enabled using "go test -race".
+	Context("when other goroutines are writing", func() {
+		It("can be changed safely", func() {
+			writers := []*recordingWriter{{}, {}, {}}
+			logger := Named("race")
+
+			stop := make(chan struct{})
+			swapped := make(chan struct{})
+			go func() {
+				defer close(swapped)
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+
+					restore := SetOutput(writers[i%len(writers)])
+					if 0 == i%3 {
+						restore()
+					}
+				}
+			}()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 200; j++ {
+						io.WriteString(ProcessMonitor, "plain")
+						Infof("leveled")
+						logger.Warnf("component")
+					}
+				}()
+			}
+
+			wg.Wait()
+			close(stop)
+			<-swapped
+
+			for _, w := range writers {
+				for _, m := range w.Messages() {
+					Expect([]string{"plain", "INFO: leveled", "race: WARN: component"}).To(ContainElement(m))
+				}
+			}
+		})
+	})
+})
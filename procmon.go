@@ -24,18 +24,31 @@ SOFTWARE.
 
 package procmon
 
-import "io"
+import (
+	"io"
+	"os"
+)
 
 // ProcessMonitor is used to write debug messages to the Process Monitor log.
-// Debug messages should be less than 2048 characters in order to display
-// correctly in the log.
+// Debug messages that are longer than MaxMessageLength UTF-16 code units are
+// split or truncated according to the current MessageSizer. Embedded NUL
+// characters and invalid UTF-8 are handled by the current Sanitizer.
 //
 // ProcessMonitor is an io.Writer type and can be used with any code that
-// supports writing to an io.Writer instance.
-var ProcessMonitor Writer
+// supports writing to an io.Writer instance. ProcessMonitor forwards debug
+// messages to the Writer that is returned by Output. Use SetOutput to
+// change where debug messages are written; assigning to ProcessMonitor is
+// not safe while other goroutines are writing debug messages.
+var ProcessMonitor Writer = outputWriter{}
 
 func init() {
-	ProcessMonitor = &nullProcessMonitor{}
+	if "" == os.Getenv(BackendEnvironmentVariable) && "" == defaultBackend {
+		return
+	}
+
+	if _, err := Open(Options{}); nil != err {
+		diagnosef("init failed: %v", err)
+	}
 }
 
 // Writer defines an interface for writing debug messages to the Process
@@ -66,3 +79,98 @@ func (pm *nullProcessMonitor) Write(p []byte) (n int, err error) {
 func (pm *nullProcessMonitor) WriteString(s string) (n int, err error) {
 	return len(s), nil
 }
+
+// The null implementation discards every message, so callers can skip
+// formatting messages for it.
+func (pm *nullProcessMonitor) discards() bool {
+	return true
+}
+
+// processMonitorWriter adapts a Backend to the Writer interface. Messages are
+// passed through the current Redactor, Sanitizer, and MessageSizer before
+// they are written so that the backend never receives sensitive text, an
+// embedded NUL character, or a message that is longer than the size limit.
+type processMonitorWriter struct {
+	backend Backend
+}
+
+func (w *processMonitorWriter) Write(p []byte) (n int, err error) {
+	// Messages that fit and do not need to be redacted or sanitized are
+	// encoded directly into a pooled buffer, so the steady-state write path
+	// does not allocate.
+	if b, ok := w.backend.(UTF16Backend); ok && nil == CurrentRedactor() &&
+		CurrentSanitizer().clean(p) &&
+		utf16LenBytes(p) <= CurrentMessageSizer().MaxLength() {
+		buf := getUTF16Buffer()
+		*buf = append(AppendUTF16(*buf, p), 0)
+		err = b.WriteUTF16(*buf)
+		putUTF16Buffer(buf)
+		if nil != err {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	return w.WriteString(string(p))
+}
+
+func (w *processMonitorWriter) WriteString(s string) (n int, err error) {
+	message := s
+	if r := CurrentRedactor(); nil != r {
+		var ok bool
+		if message, ok = r.Redact(s); !ok {
+			return len(s), nil
+		}
+	}
+
+	return w.write(s, message)
+}
+
+// write passes a message that has already been redacted through the
+// Sanitizer and MessageSizer and writes it to the backend. s is the message
+// before it was redacted and is used to report the number of bytes that
+// were written.
+func (w *processMonitorWriter) write(s, message string) (n int, err error) {
+	if b, ok := w.backend.(UTF16Backend); ok &&
+		CurrentSanitizer().cleanString(message) &&
+		UTF16Len(message) <= CurrentMessageSizer().MaxLength() {
+		buf := getUTF16Buffer()
+		*buf = append(AppendUTF16String(*buf, message), 0)
+		err = b.WriteUTF16(*buf)
+		putUTF16Buffer(buf)
+		if nil != err {
+			return 0, err
+		}
+
+		return len(s), nil
+	}
+
+	messages, err := CurrentSanitizer().Sanitize(message)
+	if nil != err {
+		return 0, err
+	}
+
+	// The number of bytes that were written is only known if the message
+	// was not changed by the Redactor or the Sanitizer. Otherwise a partial
+	// write is reported as writing nothing.
+	exact := 1 == len(messages) && messages[0] == s
+	for _, message := range messages {
+		chunks := CurrentMessageSizer().Fit(message)
+		if 0 == len(chunks) {
+			return n, ErrMessageTooLong
+		}
+
+		for i, chunk := range chunks {
+			if err = w.backend.Write(chunk); nil != err {
+				return n, err
+			}
+
+			if exact && 1 < len(chunks) {
+				n += len(chunk) - len(continuationMarker(i, len(chunks)))
+			}
+		}
+	}
+
+	return len(s), nil
+}
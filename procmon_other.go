@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import "fmt"
+
+// defaultBackend is the backend specification that is used when the
+// PROCMON_BACKEND environment variable is not set. Process Monitor is not
+// available on this platform, so the null implementation is used.
+const defaultBackend = ""
+
+// openProcessMonitor returns an error because Process Monitor is only
+// available on Microsoft Windows.
+func openProcessMonitor(arg string) (Backend, error) {
+	return nil, fmt.Errorf("%w: Process Monitor is only available on Microsoft Windows", ErrNotAvailable)
+}
+
+// deviceNotAvailable returns false because there is no Process Monitor
+// device on this platform.
+func deviceNotAvailable(err error) bool {
+	return false
+}
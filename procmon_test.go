@@ -27,6 +27,8 @@ package procmon
 import (
 	"bytes"
 	"io"
+	"strings"
+	"sync"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -71,3 +73,130 @@ var _ = Describe("nullProcessMonitor", func() {
 		})
 	})
 })
+
+type mockProcessMonitor struct {
+	message  string
+	messages []string
+	closed   bool
+}
+
+func (m *mockProcessMonitor) Write(message string) error {
+	m.message = message
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *mockProcessMonitor) Close() error {
+	m.closed = true
+	return nil
+}
+
+// recordingWriter is a Writer that records every debug message that is
+// written to it.
+type recordingWriter struct {
+	mu       sync.Mutex
+	messages []string
+	err      error
+}
+
+func (w *recordingWriter) Write(p []byte) (n int, err error) {
+	return w.WriteString(string(p))
+}
+
+func (w *recordingWriter) WriteString(s string) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if nil != w.err {
+		return 0, w.err
+	}
+
+	w.messages = append(w.messages, s)
+	return len(s), nil
+}
+
+func (w *recordingWriter) Messages() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.messages...)
+}
+
+var _ = Describe("processMonitorWriter", func() {
+	const testMessage = "Hello, World!"
+	var mock *mockProcessMonitor
+	var writer *processMonitorWriter
+
+	BeforeEach(func() {
+		mock = &mockProcessMonitor{}
+		writer = &processMonitorWriter{mock}
+	})
+
+	Describe("Write", func() {
+		var length int
+		var err error
+
+		BeforeEach(func() {
+			length, err =
+				writer.Write(bytes.NewBufferString(testMessage).Bytes())
+		})
+
+		It("writes the message to Process Monitor", func() {
+			Expect(mock.message).To(Equal(testMessage))
+		})
+
+		It("returns the length of the string", func() {
+			Expect(length).To(Equal(len(testMessage)))
+		})
+
+		It("does not return an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("WriteString", func() {
+		var length int
+		var err error
+
+		BeforeEach(func() {
+			length, err = io.WriteString(writer, testMessage)
+		})
+
+		It("writes the message to Process Monitor", func() {
+			Expect(mock.message).To(Equal(testMessage))
+		})
+
+		It("returns the length of the string", func() {
+			Expect(length).To(Equal(len(testMessage)))
+		})
+
+		It("does not return an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("WriteString with an oversized message", func() {
+		var message string
+		var length int
+		var err error
+
+		BeforeEach(func() {
+			message = strings.Repeat("x", MaxMessageLength+1)
+			length, err = writer.WriteString(message)
+		})
+
+		It("writes the message in chunks", func() {
+			Expect(mock.messages).To(HaveLen(2))
+			Expect(mock.messages[0]).To(HavePrefix("(1/2) "))
+			Expect(mock.messages[1]).To(HavePrefix("(2/2) "))
+		})
+
+		It("returns the length of the string", func() {
+			Expect(length).To(Equal(len(message)))
+		})
+
+		It("does not return an error", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+})
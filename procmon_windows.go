@@ -25,24 +25,26 @@ SOFTWARE.
 package procmon
 
 import (
-	"bytes"
-	"fmt"
-	"io"
+	"errors"
+	"strings"
 	"unsafe"
 
-	"github.com/mfcollins3/go-debugger"
-
 	"golang.org/x/sys/windows"
 )
 
-func init() {
-	io.WriteString(debugger.Console,
-		"Detecting whether Process Monitor is installed")
+// defaultBackend is the backend specification that is used when the
+// PROCMON_BACKEND environment variable is not set.
+const defaultBackend = "procmon"
+
+// openProcessMonitor opens the Process Monitor logging device. An error is
+// returned if Process Monitor is not installed or the device cannot be
+// opened.
+func openProcessMonitor(arg string) (Backend, error) {
+	diagnosef("Detecting whether Process Monitor is installed")
 
 	path, err := windows.UTF16PtrFromString(`\\.\Global\ProcmonDebugLogger`)
 	if nil != err {
-		fmt.Fprintf(debugger.Console, "init failed: %v", err)
-		return
+		return nil, err
 	}
 
 	processMonitorHandle, err := windows.CreateFile(
@@ -54,72 +56,75 @@ func init() {
 		windows.FILE_ATTRIBUTE_NORMAL,
 		0)
 	if nil != err {
-		fmt.Fprintf(debugger.Console, "CreateFile failed: %v", err)
-		return
+		return nil, &DeviceError{Op: "CreateFile", Err: err}
 	}
 
 	if windows.InvalidHandle == processMonitorHandle {
-		io.WriteString(debugger.Console, "Process Monitor is not installed")
-		return
+		return nil, ErrNotAvailable
 	}
 
-	ProcessMonitor =
-		&processMonitorWriter{&realProcessMonitor{processMonitorHandle}}
-	io.WriteString(debugger.Console, "Process Monitor debug logging is enabled")
+	return &realProcessMonitor{processMonitorHandle}, nil
 }
 
 const debugOut uint32 = (0x9535 << 16) | (0x2 << 14) | (0x81 << 2)
 
-type processMonitor interface {
-	Write(message string) error
-}
-
 type realProcessMonitor struct {
 	handle windows.Handle
 }
 
 func (pm *realProcessMonitor) Write(message string) error {
-	msg, err := windows.UTF16PtrFromString(message)
-	if nil != err {
-		return err
+	// The Sanitizer removes NUL characters before messages reach the
+	// backend, so a NUL character here was written to the backend directly.
+	if i := strings.IndexByte(message, 0); 0 <= i {
+		return &InvalidMessageError{Offset: i}
+	}
+
+	buf := getUTF16Buffer()
+	defer putUTF16Buffer(buf)
+
+	*buf = append(AppendUTF16String(*buf, message), 0)
+	return pm.WriteUTF16(*buf)
+}
+
+func (pm *realProcessMonitor) WriteUTF16(message []uint16) error {
+	if windows.InvalidHandle == pm.handle {
+		return ErrClosed
 	}
 
 	var bytesReturned uint32
-	err = windows.DeviceIoControl(pm.handle,
+	err := windows.DeviceIoControl(pm.handle,
 		debugOut,
-		(*byte)(unsafe.Pointer(msg)),
-		uint32((len(message)+1)*2),
+		(*byte)(unsafe.Pointer(&message[0])),
+		uint32(len(message)*2),
 		nil,
 		0,
 		&bytesReturned,
 		nil)
 	if nil != err {
-		fmt.Fprintf(debugger.Console, "PROCESS MONITOR ERROR: %v", err)
+		diagnosef("PROCESS MONITOR ERROR: %v", err)
+		return &DeviceError{Op: "DeviceIoControl", Err: err}
 	}
 
-	return err
+	return nil
 }
 
-type processMonitorWriter struct {
-	processMonitor processMonitor
-}
+func (pm *realProcessMonitor) Close() error {
+	if windows.InvalidHandle == pm.handle {
+		return nil
+	}
 
-func (w *processMonitorWriter) Write(p []byte) (n int, err error) {
-	_, err = w.WriteString(bytes.NewBuffer(p).String())
-	n = 0
-	if nil == err {
-		n = len(p)
+	handle := pm.handle
+	pm.handle = windows.InvalidHandle
+	if err := windows.CloseHandle(handle); nil != err {
+		return &DeviceError{Op: "CloseHandle", Err: err}
 	}
 
-	return
+	return nil
 }
 
-func (w *processMonitorWriter) WriteString(s string) (n int, err error) {
-	err = w.processMonitor.Write(s)
-	n = 0
-	if nil == err {
-		n = len(s)
-	}
-
-	return
+// deviceNotAvailable returns true if err means that the Process Monitor
+// device does not exist because Process Monitor is not running.
+func deviceNotAvailable(err error) bool {
+	return errors.Is(err, windows.ERROR_FILE_NOT_FOUND) ||
+		errors.Is(err, windows.ERROR_PATH_NOT_FOUND)
 }
@@ -0,0 +1,155 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// HaveEmitted succeeds if a Recorder has recorded a debug message that
+// contains substring.
+//
+//	Expect(recorder).To(procmontest.HaveEmitted("The program is starting"))
+func HaveEmitted(substring string) types.GomegaMatcher {
+	return &emittedMatcher{
+		description: "to contain a debug message containing",
+		expected:    []interface{}{substring},
+		match: func(texts []string) bool {
+			return 0 <= indexOf(texts, 0, func(text string) bool {
+				return strings.Contains(text, substring)
+			})
+		},
+	}
+}
+
+// HaveEmittedInOrder succeeds if a Recorder has recorded debug messages that
+// contain each of the substrings in order. Other debug messages may be
+// recorded before, between, or after the matching messages.
+func HaveEmittedInOrder(substrings ...string) types.GomegaMatcher {
+	return &emittedMatcher{
+		description: "to contain debug messages containing, in order,",
+		expected:    []interface{}{substrings},
+		match: func(texts []string) bool {
+			start := 0
+			for _, substring := range substrings {
+				i := indexOf(texts, start, func(text string) bool {
+					return strings.Contains(text, substring)
+				})
+				if 0 > i {
+					return false
+				}
+
+				start = i + 1
+			}
+
+			return true
+		},
+	}
+}
+
+// HaveEmittedMatching succeeds if a Recorder has recorded a debug message
+// that matches the regular expression pattern. Match returns an error if
+// pattern is not a valid regular expression.
+func HaveEmittedMatching(pattern string) types.GomegaMatcher {
+	re, err := regexp.Compile(pattern)
+	return &emittedMatcher{
+		description: "to contain a debug message matching",
+		expected:    []interface{}{pattern},
+		err:         err,
+		match: func(texts []string) bool {
+			return 0 <= indexOf(texts, 0, re.MatchString)
+		},
+	}
+}
+
+// emittedMatcher matches the text of the debug messages that were recorded
+// by a Recorder.
+type emittedMatcher struct {
+	description string
+	expected    []interface{}
+	err         error
+	match       func(texts []string) bool
+}
+
+func (m *emittedMatcher) Match(actual interface{}) (success bool, err error) {
+	if nil != m.err {
+		return false, m.err
+	}
+
+	texts, err := recordedTexts(actual)
+	if nil != err {
+		return false, err
+	}
+
+	return m.match(texts), nil
+}
+
+func (m *emittedMatcher) FailureMessage(actual interface{}) (message string) {
+	texts, _ := recordedTexts(actual)
+	return format.Message(texts, m.description, m.expected...)
+}
+
+func (m *emittedMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	texts, _ := recordedTexts(actual)
+	return format.Message(texts, "not "+m.description, m.expected...)
+}
+
+// recordedTexts returns the text of the debug messages that were recorded by
+// actual, which may be a *Recorder, a slice of Messages, or a slice of
+// strings.
+func recordedTexts(actual interface{}) ([]string, error) {
+	switch a := actual.(type) {
+	case *Recorder:
+		return a.Texts(), nil
+	case []Message:
+		texts := make([]string, len(a))
+		for i, m := range a {
+			texts[i] = m.Text
+		}
+
+		return texts, nil
+	case []string:
+		return a, nil
+	}
+
+	return nil, fmt.Errorf("procmontest: expected a *Recorder, []Message, or []string, got:\n%s", format.Object(actual, 1))
+}
+
+// indexOf returns the index of the first text at or after start that
+// satisfies f, or -1.
+func indexOf(texts []string, start int, f func(string) bool) int {
+	for i := start; i < len(texts); i++ {
+		if f(texts[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,85 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Matchers", func() {
+	var recorder *Recorder
+
+	BeforeEach(func() {
+		recorder = NewRecorder()
+		recorder.Write("opening C:\\data\\file.txt")
+		recorder.Write("reading 42 bytes")
+		recorder.Write("closing C:\\data\\file.txt")
+	})
+
+	Describe("HaveEmitted", func() {
+		It("matches a debug message containing the substring", func() {
+			Expect(recorder).To(HaveEmitted("42 bytes"))
+			Expect(recorder).NotTo(HaveEmitted("writing"))
+		})
+
+		It("accepts recorded messages", func() {
+			Expect(recorder.Messages()).To(HaveEmitted("reading"))
+			Expect(recorder.Texts()).To(HaveEmitted("reading"))
+		})
+
+		It("fails for other types", func() {
+			_, err := HaveEmitted("reading").Match(42)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("lists the recorded messages in the failure message", func() {
+			message := HaveEmitted("writing").FailureMessage(recorder)
+			Expect(message).To(ContainSubstring("reading 42 bytes"))
+			Expect(message).To(ContainSubstring("writing"))
+		})
+	})
+
+	Describe("HaveEmittedInOrder", func() {
+		It("matches debug messages in order", func() {
+			Expect(recorder).To(HaveEmittedInOrder("opening", "closing"))
+			Expect(recorder).To(HaveEmittedInOrder("file.txt", "file.txt"))
+			Expect(recorder).NotTo(HaveEmittedInOrder("closing", "opening"))
+			Expect(recorder).NotTo(HaveEmittedInOrder("reading", "reading"))
+		})
+	})
+
+	Describe("HaveEmittedMatching", func() {
+		It("matches a debug message using a regular expression", func() {
+			Expect(recorder).To(HaveEmittedMatching(`^reading \d+ bytes$`))
+			Expect(recorder).NotTo(HaveEmittedMatching(`^writing`))
+		})
+
+		It("fails if the regular expression is not valid", func() {
+			_, err := HaveEmittedMatching("(").Match(recorder)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
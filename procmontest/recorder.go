@@ -0,0 +1,171 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/goid"
+)
+
+// Message is a debug message that was recorded by a Recorder.
+type Message struct {
+	// Time is the time that the debug message was written.
+	Time time.Time
+
+	// Goroutine is the ID of the goroutine that wrote the debug message.
+	Goroutine uint64
+
+	// Component is the name of the component that wrote the debug message,
+	// or an empty string. The component is only known for debug messages
+	// that are written through the writer that is installed by Install.
+	Component string
+
+	// Text is the text of the debug message as it would have been sent to
+	// Process Monitor.
+	Text string
+}
+
+// Recorder is a procmon.Backend that keeps every debug message that is
+// written to it in memory. A Recorder is safe to use from multiple
+// goroutines.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Write records a debug message.
+func (r *Recorder) Write(message string) error {
+	return r.record(message, "")
+}
+
+func (r *Recorder) record(message, component string) error {
+	m := Message{
+		Time:      time.Now(),
+		Goroutine: goid.Current(),
+		Component: component,
+		Text:      message,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+// Close does nothing. Messages can still be recorded after the Recorder is
+// closed so that messages written during shutdown are not lost.
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// Messages returns a copy of the debug messages that have been recorded.
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Message(nil), r.messages...)
+}
+
+// Texts returns the text of the debug messages that have been recorded.
+func (r *Recorder) Texts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	texts := make([]string, len(r.messages))
+	for i, m := range r.messages {
+		texts[i] = m.Text
+	}
+
+	return texts
+}
+
+// Reset discards the debug messages that have been recorded.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = nil
+}
+
+// Install replaces the output of procmon.ProcessMonitor with a writer that
+// records debug messages in a new Recorder. The original writer is restored
+// when the test finishes. Debug messages are passed through the current
+// Redactor, Sanitizer, and MessageSizer before they are recorded, just like
+// they would be before being sent to Process Monitor.
+func Install(t testing.TB) *Recorder {
+	r := NewRecorder()
+	t.Cleanup(procmon.SetOutput(&recordingWriter{
+		w: procmon.NewWriter(r),
+		r: r,
+	}))
+
+	return r
+}
+
+// recordingWriter writes debug messages to a Recorder. The debug messages
+// that an entry is split into are recorded with the component of the entry.
+type recordingWriter struct {
+	w procmon.Writer
+	r *Recorder
+}
+
+func (w *recordingWriter) Write(p []byte) (n int, err error) {
+	return w.w.Write(p)
+}
+
+func (w *recordingWriter) WriteString(s string) (n int, err error) {
+	return w.w.WriteString(s)
+}
+
+func (w *recordingWriter) WriteEntry(e *procmon.Entry) error {
+	b := componentBackend{r: w.r, component: e.Component}
+	_, err := procmon.NewWriter(b).WriteString(e.Message)
+	return err
+}
+
+// componentBackend records debug messages in a Recorder with the component
+// of the entry that they were written for.
+type componentBackend struct {
+	r         *Recorder
+	component string
+}
+
+func (b componentBackend) Write(message string) error {
+	return b.r.record(message, b.component)
+}
+
+func (b componentBackend) Close() error {
+	return nil
+}
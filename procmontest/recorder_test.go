@@ -0,0 +1,140 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/goid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recorder", func() {
+	var recorder *Recorder
+
+	BeforeEach(func() {
+		recorder = NewRecorder()
+	})
+
+	It("records every debug message", func() {
+		w := procmon.NewWriter(recorder)
+		io.WriteString(w, "one")
+		io.WriteString(w, "two")
+		Expect(recorder.Texts()).To(Equal([]string{"one", "two"}))
+	})
+
+	It("records the time and goroutine of each debug message", func() {
+		recorder.Write("message")
+		m := recorder.Messages()[0]
+		Expect(m.Time).NotTo(BeZero())
+		Expect(m.Goroutine).To(Equal(goid.Current()))
+	})
+
+	It("is safe to use from multiple goroutines", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					recorder.Write("message")
+				}
+			}()
+		}
+
+		wg.Wait()
+		Expect(recorder.Messages()).To(HaveLen(1000))
+	})
+
+	It("discards the recorded messages when reset", func() {
+		recorder.Write("message")
+		recorder.Reset()
+		Expect(recorder.Messages()).To(BeEmpty())
+	})
+})
+
+func TestInstall(t *testing.T) {
+	original := procmon.Output()
+	t.Run("install", func(t *testing.T) {
+		recorder := Install(t)
+		procmon.Named("db").Infof("connected")
+		io.WriteString(procmon.ProcessMonitor, "plain")
+
+		messages := recorder.Messages()
+		if 2 != len(messages) {
+			t.Fatalf("expected 2 messages, got %d", len(messages))
+		}
+
+		if "db" != messages[0].Component || "db: INFO: connected" != messages[0].Text {
+			t.Errorf("unexpected message: %+v", messages[0])
+		}
+
+		if "" != messages[1].Component || "plain" != messages[1].Text {
+			t.Errorf("unexpected message: %+v", messages[1])
+		}
+	})
+
+	if original != procmon.Output() {
+		t.Error("ProcessMonitor was not restored")
+	}
+}
+
+func TestInstallComponents(t *testing.T) {
+	recorder := Install(t)
+
+	// The rule writes to the Recorder directly while an entry from the db
+	// component is being written, as another writer that shares the
+	// Recorder might.
+	procmon.SetRedactor(procmon.NewRedactor(procmon.RedactionRule{
+		Find: func(message string) [][]int {
+			if strings.HasSuffix(message, "connected") {
+				recorder.Write("plain")
+			}
+
+			return nil
+		},
+	}))
+	defer procmon.SetRedactor(nil)
+
+	procmon.Named("db").Infof("connected")
+
+	messages := recorder.Messages()
+	if 2 != len(messages) {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+
+	if "" != messages[0].Component || "plain" != messages[0].Text {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+
+	if "db" != messages[1].Component || "db: INFO: connected" != messages[1].Text {
+		t.Errorf("unexpected message: %+v", messages[1])
+	}
+}
@@ -0,0 +1,187 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// GinkgoReporter is a Ginkgo reporter that writes a debug message when a
+// suite or spec starts and ends. The messages mark where each spec starts
+// and stops in a Process Monitor log, which helps to find the spec that
+// accessed a file or registry key. GinkgoReporter implements the
+// reporters.Reporter interface and is added to a suite using
+// RunSpecsWithDefaultAndCustomReporters:
+//
+//	func TestMyPackage(t *testing.T) {
+//		RegisterFailHandler(Fail)
+//		RunSpecsWithDefaultAndCustomReporters(t, "MyPackage Suite",
+//			[]Reporter{procmontest.NewGinkgoReporter()})
+//	}
+type GinkgoReporter struct {
+	// Writer receives the debug messages. If Writer is nil, the debug
+	// messages are written to procmon.ProcessMonitor.
+	Writer procmon.Writer
+}
+
+// NewGinkgoReporter returns a GinkgoReporter that writes to
+// procmon.ProcessMonitor.
+func NewGinkgoReporter() *GinkgoReporter {
+	return &GinkgoReporter{}
+}
+
+// SpecSuiteWillBegin writes a debug message when a suite starts.
+func (r *GinkgoReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.printf("ginkgo: suite %q starting (%d of %d specs will run)",
+		summary.SuiteDescription,
+		summary.NumberOfSpecsThatWillBeRun,
+		summary.NumberOfTotalSpecs)
+}
+
+// BeforeSuiteDidRun writes a debug message after the BeforeSuite node runs.
+func (r *GinkgoReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.setupDidRun("BeforeSuite", setupSummary)
+}
+
+// SpecWillRun writes a debug message before a spec runs.
+func (r *GinkgoReporter) SpecWillRun(specSummary *types.SpecSummary) {
+	r.printf("ginkgo: spec starting: %s", specText(specSummary))
+}
+
+// SpecDidComplete writes a debug message after a spec runs with the state
+// and duration of the spec.
+func (r *GinkgoReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+	r.printf("ginkgo: spec %s in %v: %s",
+		stateName(specSummary.State),
+		specSummary.RunTime,
+		specText(specSummary))
+}
+
+// AfterSuiteDidRun writes a debug message after the AfterSuite node runs.
+func (r *GinkgoReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.setupDidRun("AfterSuite", setupSummary)
+}
+
+// SpecSuiteDidEnd writes a debug message when a suite ends.
+func (r *GinkgoReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	result := "passed"
+	if !summary.SuiteSucceeded {
+		result = "failed"
+	}
+
+	r.printf("ginkgo: suite %q %s in %v (%d passed, %d failed, %d pending, %d skipped)",
+		summary.SuiteDescription,
+		result,
+		summary.RunTime,
+		summary.NumberOfPassedSpecs,
+		summary.NumberOfFailedSpecs,
+		summary.NumberOfPendingSpecs,
+		summary.NumberOfSkippedSpecs)
+}
+
+func (r *GinkgoReporter) setupDidRun(name string, setupSummary *types.SetupSummary) {
+	// Ginkgo reports setup nodes even if the suite does not have them.
+	if types.SpecStateInvalid == setupSummary.State {
+		return
+	}
+
+	r.printf("ginkgo: %s %s in %v", name, stateName(setupSummary.State), setupSummary.RunTime)
+}
+
+func (r *GinkgoReporter) printf(format string, a ...interface{}) {
+	// ProcessMonitor forwards to the current output when it is written to,
+	// so the markers follow SetOutput and are kept by the flight recorder.
+	w := r.Writer
+	if nil == w {
+		w = procmon.ProcessMonitor
+	}
+
+	io.WriteString(w, fmt.Sprintf(format, a...))
+}
+
+// specText returns the full text of a spec, without the text of the top
+// level container.
+func specText(specSummary *types.SpecSummary) string {
+	texts := specSummary.ComponentTexts
+	if 1 < len(texts) {
+		texts = texts[1:]
+	}
+
+	return strings.Join(texts, " ")
+}
+
+func stateName(state types.SpecState) string {
+	switch state {
+	case types.SpecStatePending:
+		return "pending"
+	case types.SpecStateSkipped:
+		return "skipped"
+	case types.SpecStatePassed:
+		return "passed"
+	case types.SpecStateFailed:
+		return "failed"
+	case types.SpecStatePanicked:
+		return "panicked"
+	case types.SpecStateTimedOut:
+		return "timed out"
+	}
+
+	return "invalid"
+}
+
+// MarkTest writes a debug message to procmon.ProcessMonitor when a test
+// starts and another when the test ends, so that the debug messages and
+// events that were logged by the test can be found in the Process Monitor
+// log. MarkTest should be called at the beginning of the test:
+//
+//	func TestOpenConfig(t *testing.T) {
+//		procmontest.MarkTest(t)
+//		...
+//	}
+func MarkTest(t testing.TB) {
+	name := t.Name()
+	start := time.Now()
+	io.WriteString(procmon.ProcessMonitor, "test starting: "+name)
+	t.Cleanup(func() {
+		result := "passed"
+		switch {
+		case t.Skipped():
+			result = "skipped"
+		case t.Failed():
+			result = "failed"
+		}
+
+		io.WriteString(procmon.ProcessMonitor,
+			fmt.Sprintf("test %s in %v: %s", result, time.Since(start), name))
+	})
+}
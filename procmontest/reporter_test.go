@@ -0,0 +1,128 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmontest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/types"
+	. "github.com/onsi/gomega"
+)
+
+var _ reporters.Reporter = &GinkgoReporter{}
+
+var _ = Describe("GinkgoReporter", func() {
+	var recorder *Recorder
+	var reporter *GinkgoReporter
+
+	BeforeEach(func() {
+		recorder = NewRecorder()
+		reporter = &GinkgoReporter{Writer: procmon.NewWriter(recorder)}
+	})
+
+	It("marks the beginning and end of a suite", func() {
+		summary := &types.SuiteSummary{
+			SuiteDescription:           "Files Suite",
+			NumberOfTotalSpecs:         3,
+			NumberOfSpecsThatWillBeRun: 2,
+		}
+		reporter.SpecSuiteWillBegin(config.GinkgoConfig, summary)
+
+		summary.SuiteSucceeded = true
+		summary.RunTime = time.Second
+		summary.NumberOfPassedSpecs = 2
+		summary.NumberOfSkippedSpecs = 1
+		reporter.SpecSuiteDidEnd(summary)
+
+		Expect(recorder.Texts()).To(Equal([]string{
+			`ginkgo: suite "Files Suite" starting (2 of 3 specs will run)`,
+			`ginkgo: suite "Files Suite" passed in 1s (2 passed, 0 failed, 0 pending, 1 skipped)`,
+		}))
+	})
+
+	It("marks the beginning and end of a spec", func() {
+		summary := &types.SpecSummary{
+			ComponentTexts: []string{"[Top Level]", "Config", "opens the file"},
+		}
+		reporter.SpecWillRun(summary)
+
+		summary.State = types.SpecStateFailed
+		summary.RunTime = 15 * time.Millisecond
+		reporter.SpecDidComplete(summary)
+
+		Expect(recorder.Texts()).To(Equal([]string{
+			"ginkgo: spec starting: Config opens the file",
+			"ginkgo: spec failed in 15ms: Config opens the file",
+		}))
+	})
+
+	It("marks setup nodes that ran", func() {
+		reporter.BeforeSuiteDidRun(&types.SetupSummary{})
+		reporter.AfterSuiteDidRun(&types.SetupSummary{
+			State:   types.SpecStatePassed,
+			RunTime: time.Millisecond,
+		})
+
+		Expect(recorder.Texts()).To(Equal([]string{"ginkgo: AfterSuite passed in 1ms"}))
+	})
+
+	It("writes to ProcessMonitor by default", func() {
+		defer procmon.SetOutput(procmon.NewWriter(recorder))()
+
+		NewGinkgoReporter().SpecWillRun(&types.SpecSummary{
+			ComponentTexts: []string{"[Top Level]", "spec"},
+		})
+		Expect(recorder).To(HaveEmitted("ginkgo: spec starting: spec"))
+	})
+})
+
+func TestMarkTest(t *testing.T) {
+	var recorder *Recorder
+	t.Run("marked", func(t *testing.T) {
+		recorder = Install(t)
+		MarkTest(t)
+		procmon.Print("working")
+	})
+
+	texts := recorder.Texts()
+	if 3 != len(texts) {
+		t.Fatalf("expected 3 messages, got %q", texts)
+	}
+
+	if "test starting: TestMarkTest/marked" != texts[0] {
+		t.Errorf("unexpected start marker: %q", texts[0])
+	}
+
+	if !strings.HasPrefix(texts[2], "test passed in ") ||
+		!strings.HasSuffix(texts[2], ": TestMarkTest/marked") {
+		t.Errorf("unexpected end marker: %q", texts[2])
+	}
+}
@@ -0,0 +1,412 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitKey selects how a RateLimiter groups debug messages. Each group
+// has its own limit.
+type RateLimitKey int
+
+const (
+	// ByCallSite limits the debug messages that are written by each line of
+	// code separately.
+	ByCallSite RateLimitKey = 1 << iota
+
+	// ByComponent limits the debug messages that are written by each named
+	// component separately.
+	ByComponent
+)
+
+// RateLimitOptions configures a RateLimiter. The token bucket and the
+// "first N then every Mth" limits can be used separately or together. When
+// both are configured, a message must pass both limits to be written.
+type RateLimitOptions struct {
+	// Key selects how messages are grouped. If Key is zero, messages are
+	// grouped by call site.
+	Key RateLimitKey
+
+	// Rate is the number of messages per second that each group can write
+	// over the long term. If Rate is zero, the token bucket limit is not
+	// used.
+	Rate float64
+
+	// Burst is the number of messages that each group can write at once
+	// before Rate applies. If Burst is zero, a burst of 1 is used.
+	Burst int
+
+	// First is the number of messages that each group writes in each
+	// Interval before the Thereafter limit applies. If First is zero, the
+	// "first N then every Mth" limit is not used.
+	First int
+
+	// Thereafter causes every Thereafter-th message after the first First
+	// messages to be written. If Thereafter is zero, no messages are written
+	// after the first First messages until the interval ends.
+	Thereafter int
+
+	// Interval is the period after which the First and Thereafter counts
+	// start over. If Interval is zero, the counts never start over.
+	Interval time.Duration
+}
+
+// RateLimiter limits how often debug messages from the same call site or
+// component are written so that a tight loop cannot flood a Process Monitor
+// capture. When messages are suppressed, a summary such as "procmon:
+// suppressed 37 messages from main.go:42" is written before the next message
+// from the same group, or when Flush is called.
+type RateLimiter struct {
+	w      Writer
+	opts   RateLimitOptions
+	now    func() time.Time
+	mu     sync.Mutex
+	groups map[string]*rateGroup
+}
+
+// rateGroup tracks the limits of one group of debug messages.
+type rateGroup struct {
+	tokens     float64
+	refilled   time.Time
+	count      int
+	started    time.Time
+	suppressed int
+	component  string
+}
+
+// NewRateLimiter returns a RateLimiter that writes the debug messages that
+// pass its limits to w.
+func NewRateLimiter(w Writer, opts RateLimitOptions) *RateLimiter {
+	if 0 == opts.Key {
+		opts.Key = ByCallSite
+	}
+
+	if 0 >= opts.Burst {
+		opts.Burst = 1
+	}
+
+	return &RateLimiter{
+		w:      w,
+		opts:   opts,
+		now:    time.Now,
+		groups: make(map[string]*rateGroup),
+	}
+}
+
+// Write writes p as a debug message if its group is within its limits.
+func (r *RateLimiter) Write(p []byte) (n int, err error) {
+	if err = r.WriteEntry(newEntry(string(p))); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString writes s as a debug message if its group is within its
+// limits.
+func (r *RateLimiter) WriteString(s string) (n int, err error) {
+	if err = r.WriteEntry(newEntry(s)); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// WriteEntry writes e if its group is within its limits. A summary of the
+// messages that were suppressed since the last message from the group is
+// written first.
+func (r *RateLimiter) WriteEntry(e *Entry) error {
+	key := r.key(e)
+
+	r.mu.Lock()
+	g, ok := r.groups[key]
+	if !ok {
+		g = &rateGroup{tokens: float64(r.opts.Burst), component: e.Component}
+		r.groups[key] = g
+	}
+
+	if !r.allow(g) {
+		g.suppressed++
+		r.mu.Unlock()
+		return nil
+	}
+
+	suppressed := g.suppressed
+	g.suppressed = 0
+	r.mu.Unlock()
+
+	if 0 < suppressed {
+		if err := r.writeSummary(key, g.component, suppressed); nil != err {
+			return err
+		}
+	}
+
+	return WriteEntry(r.w, e)
+}
+
+// Flush writes a summary for each group that has suppressed messages since
+// its last message was written.
+func (r *RateLimiter) Flush() error {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.groups))
+	for key, g := range r.groups {
+		if 0 < g.suppressed {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	summaries := make([]rateGroup, len(keys))
+	for i, key := range keys {
+		summaries[i] = *r.groups[key]
+		r.groups[key].suppressed = 0
+	}
+	r.mu.Unlock()
+
+	for i, key := range keys {
+		err := r.writeSummary(key, summaries[i].component, summaries[i].suppressed)
+		if nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RateLimiter) discards() bool {
+	return discards(r.w)
+}
+
+// key returns the name of the group that e belongs to.
+func (r *RateLimiter) key(e *Entry) string {
+	var site string
+	if 0 != r.opts.Key&ByCallSite {
+		if frame, ok := callerFrame(); ok {
+			site = frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+	}
+
+	switch {
+	case 0 == r.opts.Key&ByComponent:
+		return site
+	case "" == site:
+		return e.Component
+	}
+
+	return e.Component + "@" + site
+}
+
+// allow returns true if the next message in g passes the limits. The caller
+// must hold the lock.
+func (r *RateLimiter) allow(g *rateGroup) bool {
+	now := r.now()
+	if 0 < r.opts.Rate {
+		if !g.refilled.IsZero() {
+			elapsed := now.Sub(g.refilled).Seconds()
+			g.tokens = math.Min(float64(r.opts.Burst), g.tokens+elapsed*r.opts.Rate)
+		}
+
+		g.refilled = now
+		if 1 > g.tokens {
+			return false
+		}
+	}
+
+	if 0 < r.opts.First {
+		if 0 < r.opts.Interval && now.Sub(g.started) >= r.opts.Interval {
+			g.started = now
+			g.count = 0
+		}
+
+		g.count++
+		if g.count > r.opts.First {
+			after := g.count - r.opts.First
+			if 0 >= r.opts.Thereafter || 0 != after%r.opts.Thereafter {
+				return false
+			}
+		}
+	}
+
+	if 0 < r.opts.Rate {
+		g.tokens--
+	}
+
+	return true
+}
+
+func (r *RateLimiter) writeSummary(key, component string, suppressed int) error {
+	return WriteEntry(r.w, &Entry{
+		Time:      r.now(),
+		Level:     LevelWarn,
+		Component: component,
+		Message: fmt.Sprintf("procmon: suppressed %d messages from %s",
+			suppressed, key),
+	})
+}
+
+// SamplerOptions configures a Sampler.
+type SamplerOptions struct {
+	// Rate is the fraction of debug messages that are written, between 0
+	// and 1.
+	Rate float64
+}
+
+// Sampler writes a random fraction of the debug messages that are written to
+// it. Messages with the same trace ID are either all written or all
+// suppressed, so that a sampled request can be followed from beginning to
+// end. Messages without a trace ID are sampled individually. Suppressed
+// messages are counted by trace ID, or by call site for messages without a
+// trace ID. Before the next message is written, or when Flush is called, a
+// summary such as "procmon: sampled out 12 messages from trace 4bf92f35" is
+// written for each of them.
+type Sampler struct {
+	w          Writer
+	rate       float64
+	mu         sync.Mutex
+	random     *rand.Rand
+	suppressed map[string]int
+}
+
+// NewSampler returns a Sampler that writes the sampled debug messages to w.
+func NewSampler(w Writer, opts SamplerOptions) *Sampler {
+	return &Sampler{
+		w:          w,
+		rate:       opts.Rate,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		suppressed: make(map[string]int),
+	}
+}
+
+// Write writes p as a debug message if it is sampled.
+func (s *Sampler) Write(p []byte) (n int, err error) {
+	if err = s.WriteEntry(newEntry(string(p))); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString writes s as a debug message if it is sampled.
+func (s *Sampler) WriteString(str string) (n int, err error) {
+	if err = s.WriteEntry(newEntry(str)); nil != err {
+		return 0, err
+	}
+
+	return len(str), nil
+}
+
+// WriteEntry writes e if it is sampled. The summaries of the messages that
+// were suppressed since the last message was written are written first.
+func (s *Sampler) WriteEntry(e *Entry) error {
+	s.mu.Lock()
+	if !s.sampled(e) {
+		s.suppressed[s.source(e)]++
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	if err := s.Flush(); nil != err {
+		return err
+	}
+
+	return WriteEntry(s.w, e)
+}
+
+// Flush writes a summary of the messages that were suppressed from each
+// trace or call site since the last message was written.
+func (s *Sampler) Flush() error {
+	s.mu.Lock()
+	if 0 == len(s.suppressed) {
+		s.mu.Unlock()
+		return nil
+	}
+
+	suppressed := s.suppressed
+	s.suppressed = make(map[string]int)
+	s.mu.Unlock()
+
+	sources := make([]string, 0, len(suppressed))
+	for source := range suppressed {
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+	for _, source := range sources {
+		if err := s.writeSummary(source, suppressed[source]); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Sampler) discards() bool {
+	return discards(s.w)
+}
+
+// sampled decides whether e is written. Messages with a trace ID are
+// sampled by hashing the trace ID so that the decision is the same for
+// every message in the trace. The caller must hold the lock.
+func (s *Sampler) sampled(e *Entry) bool {
+	if "" == e.TraceID {
+		return s.random.Float64() < s.rate
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(e.TraceID))
+	return float64(h.Sum64())/math.MaxUint64 < s.rate
+}
+
+// source returns where a suppressed message came from: its trace, or the
+// call site that wrote it if it does not belong to a trace.
+func (s *Sampler) source(e *Entry) string {
+	if "" != e.TraceID {
+		return "trace " + e.TraceID
+	}
+
+	if frame, ok := callerFrame(); ok {
+		return frame.File + ":" + strconv.Itoa(frame.Line)
+	}
+
+	return "unknown caller"
+}
+
+func (s *Sampler) writeSummary(source string, suppressed int) error {
+	return WriteEntry(s.w, &Entry{
+		Time:  time.Now(),
+		Level: LevelInfo,
+		Message: fmt.Sprintf("procmon: sampled out %d messages from %s",
+			suppressed, source),
+	})
+}
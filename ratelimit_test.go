@@ -0,0 +1,201 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var recorder *entryRecorder
+	var now time.Time
+
+	newLimiter := func(opts RateLimitOptions) *RateLimiter {
+		r := NewRateLimiter(recorder, opts)
+		r.now = func() time.Time { return now }
+		return r
+	}
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+		now = time.Date(2015, 10, 21, 16, 29, 0, 0, time.UTC)
+	})
+
+	Context("with a token bucket", func() {
+		It("writes a burst of messages and then limits the rate", func() {
+			limiter := newLimiter(RateLimitOptions{Rate: 1, Burst: 2})
+			write := func(i int) {
+				fmt.Fprintf(limiter, "message %d", i)
+			}
+
+			for i := 0; i < 5; i++ {
+				write(i)
+			}
+
+			Expect(recorder.Messages()).To(Equal([]string{"message 0", "message 1"}))
+
+			now = now.Add(time.Second)
+			write(5)
+			messages := recorder.Messages()
+			Expect(messages).To(HaveLen(4))
+			Expect(messages[2]).To(MatchRegexp(
+				`^procmon: suppressed 3 messages from \S+\.go:\d+$`))
+			Expect(messages[3]).To(Equal("message 5"))
+		})
+	})
+
+	Context("with first N then every Mth", func() {
+		It("writes the first messages and then every Mth message", func() {
+			limiter := newLimiter(RateLimitOptions{First: 2, Thereafter: 3})
+			for i := 1; i <= 8; i++ {
+				fmt.Fprintf(limiter, "%d", i)
+			}
+
+			messages := recorder.Messages()
+			Expect(messages).To(HaveLen(6))
+			Expect(messages[:2]).To(Equal([]string{"1", "2"}))
+			Expect(messages[2]).To(HavePrefix("procmon: suppressed 2 messages from "))
+			Expect(messages[3]).To(Equal("5"))
+			Expect(messages[4]).To(HavePrefix("procmon: suppressed 2 messages from "))
+			Expect(messages[5]).To(Equal("8"))
+		})
+
+		It("starts counting again after the interval", func() {
+			limiter := newLimiter(RateLimitOptions{First: 1, Interval: time.Second})
+			write := func() {
+				io.WriteString(limiter, "message")
+			}
+
+			for i := 0; i < 3; i++ {
+				write()
+			}
+
+			now = now.Add(time.Second)
+			write()
+			Expect(recorder.Messages()).To(HaveLen(3))
+			Expect(recorder.Messages()[1]).To(HavePrefix("procmon: suppressed 2 messages"))
+		})
+	})
+
+	Context("grouped by component", func() {
+		It("limits each component separately", func() {
+			limiter := newLimiter(RateLimitOptions{Key: ByComponent, First: 1})
+			logger := New(limiter)
+			for i := 0; i < 3; i++ {
+				logger.Named("storage").Print("storage")
+				logger.Named("network").Print("network")
+			}
+
+			Expect(recorder.Messages()).To(Equal([]string{
+				"storage: storage",
+				"network: network",
+			}))
+
+			Expect(limiter.Flush()).To(Succeed())
+			Expect(recorder.Messages()[2:]).To(Equal([]string{
+				"procmon: suppressed 2 messages from network",
+				"procmon: suppressed 2 messages from storage",
+			}))
+			Expect(recorder.Entries()[3].Component).To(Equal("storage"))
+		})
+	})
+})
+
+var _ = Describe("Sampler", func() {
+	var recorder *entryRecorder
+
+	BeforeEach(func() {
+		recorder = &entryRecorder{}
+	})
+
+	It("writes every message when the rate is 1", func() {
+		sampler := NewSampler(recorder, SamplerOptions{Rate: 1})
+		for i := 0; i < 10; i++ {
+			io.WriteString(sampler, "message")
+		}
+
+		Expect(recorder.Messages()).To(HaveLen(10))
+	})
+
+	It("writes no messages when the rate is 0", func() {
+		sampler := NewSampler(recorder, SamplerOptions{Rate: 0})
+		for i := 0; i < 10; i++ {
+			io.WriteString(sampler, "message")
+		}
+
+		Expect(recorder.Messages()).To(BeEmpty())
+		Expect(sampler.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(HaveLen(1))
+		Expect(recorder.Messages()[0]).To(MatchRegexp(
+			`^procmon: sampled out 10 messages from \S+\.go:\d+$`))
+	})
+
+	It("summarizes the suppressed messages of each trace", func() {
+		sampler := NewSampler(recorder, SamplerOptions{Rate: 0})
+		logger := New(sampler)
+		logger.WithTraceID("4bf92f35").Print("one")
+		logger.WithTraceID("00f067aa").Print("two")
+		logger.WithTraceID("4bf92f35").Print("three")
+
+		Expect(sampler.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{
+			"procmon: sampled out 1 messages from trace 00f067aa",
+			"procmon: sampled out 2 messages from trace 4bf92f35",
+		}))
+
+		Expect(sampler.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(HaveLen(2))
+	})
+
+	It("makes the same decision for every message in a trace", func() {
+		sampler := NewSampler(recorder, SamplerOptions{Rate: 0.5})
+		logger := New(sampler)
+		for trace := 0; trace < 20; trace++ {
+			traced := logger.WithTraceID(fmt.Sprintf("trace-%d", trace))
+			for i := 0; i < 5; i++ {
+				traced.Printf("trace-%d", trace)
+			}
+		}
+
+		counts := make(map[string]int)
+		for _, m := range recorder.Messages() {
+			if !strings.HasPrefix(m, "procmon:") {
+				counts[m]++
+			}
+		}
+
+		Expect(counts).NotTo(BeEmpty())
+		Expect(len(counts)).To(BeNumerically("<", 20))
+		for _, count := range counts {
+			Expect(count).To(Equal(5))
+		}
+	})
+})
@@ -0,0 +1,232 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// NULPolicy determines what happens to NUL characters that are embedded in a
+// debug message. Process Monitor receives debug messages as NUL-terminated
+// strings, so an embedded NUL character would end the message early.
+type NULPolicy int
+
+const (
+	// EscapeNUL replaces each NUL character with the escape sequence
+	// "\x00".
+	EscapeNUL NULPolicy = iota
+
+	// ReplaceNUL replaces each NUL character with U+FFFD.
+	ReplaceNUL
+
+	// SplitAtNUL writes the text between NUL characters as separate debug
+	// messages. Empty messages are not written.
+	SplitAtNUL
+
+	// StrictNUL rejects messages that contain a NUL character with an
+	// *InvalidMessageError.
+	StrictNUL
+)
+
+// String returns the name of the NUL policy.
+func (p NULPolicy) String() string {
+	switch p {
+	case EscapeNUL:
+		return "escape"
+	case ReplaceNUL:
+		return "replace"
+	case SplitAtNUL:
+		return "split"
+	case StrictNUL:
+		return "strict"
+	}
+
+	return "NULPolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// InvalidUTF8Policy determines what happens to bytes in a debug message that
+// are not valid UTF-8.
+type InvalidUTF8Policy int
+
+const (
+	// ReplaceInvalidUTF8 replaces each invalid byte with U+FFFD.
+	ReplaceInvalidUTF8 InvalidUTF8Policy = iota
+
+	// EscapeInvalidUTF8 replaces each invalid byte with an escape sequence
+	// such as "\xff".
+	EscapeInvalidUTF8
+
+	// StrictUTF8 rejects messages that are not valid UTF-8 with an
+	// *InvalidMessageError.
+	StrictUTF8
+)
+
+// String returns the name of the invalid UTF-8 policy.
+func (p InvalidUTF8Policy) String() string {
+	switch p {
+	case ReplaceInvalidUTF8:
+		return "replace"
+	case EscapeInvalidUTF8:
+		return "escape"
+	case StrictUTF8:
+		return "strict"
+	}
+
+	return "InvalidUTF8Policy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// InvalidMessageError is returned when a debug message is rejected by a
+// strict Sanitizer policy.
+type InvalidMessageError struct {
+	// Offset is the offset of the rejected byte in the message.
+	Offset int
+
+	// Byte is the value of the rejected byte.
+	Byte byte
+}
+
+func (e *InvalidMessageError) Error() string {
+	if 0 == e.Byte {
+		return fmt.Sprintf("procmon: message contains a NUL character at offset %d", e.Offset)
+	}
+
+	return fmt.Sprintf("procmon: message contains invalid UTF-8 byte %#02x at offset %d", e.Byte, e.Offset)
+}
+
+// Sanitizer cleans up debug messages that contain NUL characters or invalid
+// UTF-8 before they are encoded for Process Monitor. The zero value escapes
+// NUL characters and replaces invalid UTF-8 bytes with U+FFFD.
+type Sanitizer struct {
+	// NUL determines what is done with embedded NUL characters.
+	NUL NULPolicy
+
+	// InvalidUTF8 determines what is done with bytes that are not valid
+	// UTF-8.
+	InvalidUTF8 InvalidUTF8Policy
+}
+
+var sanitizer atomic.Value
+
+func init() {
+	sanitizer.Store(Sanitizer{})
+}
+
+// SetSanitizer changes how the Process Monitor writer handles debug messages
+// that contain NUL characters or invalid UTF-8. SetSanitizer is safe to call
+// while other goroutines are writing debug messages.
+func SetSanitizer(s Sanitizer) {
+	sanitizer.Store(s)
+}
+
+// CurrentSanitizer returns the Sanitizer that is used by the Process Monitor
+// writer.
+func CurrentSanitizer() Sanitizer {
+	return sanitizer.Load().(Sanitizer)
+}
+
+// Sanitize returns the debug messages that should be written in place of
+// message. Clean messages are returned unchanged. An *InvalidMessageError is
+// returned if message is rejected by a strict policy.
+func (s Sanitizer) Sanitize(message string) ([]string, error) {
+	if 0 > strings.IndexByte(message, 0) && utf8.ValidString(message) {
+		return []string{message}, nil
+	}
+
+	var messages []string
+	var b strings.Builder
+	for i := 0; i < len(message); {
+		c := message[i]
+		if 0 == c {
+			switch s.NUL {
+			case StrictNUL:
+				return nil, &InvalidMessageError{Offset: i}
+			case ReplaceNUL:
+				b.WriteRune(utf8.RuneError)
+			case SplitAtNUL:
+				if 0 < b.Len() {
+					messages = append(messages, b.String())
+					b.Reset()
+				}
+			default:
+				b.WriteString(`\x00`)
+			}
+
+			i++
+			continue
+		}
+
+		if c < utf8.RuneSelf {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(message[i:])
+		if utf8.RuneError == r && 1 == size {
+			switch s.InvalidUTF8 {
+			case StrictUTF8:
+				return nil, &InvalidMessageError{Offset: i, Byte: c}
+			case EscapeInvalidUTF8:
+				fmt.Fprintf(&b, `\x%02x`, c)
+			default:
+				b.WriteRune(utf8.RuneError)
+			}
+		} else {
+			b.WriteString(message[i : i+size])
+		}
+
+		i += size
+	}
+
+	if 0 < b.Len() || 0 == len(messages) {
+		messages = append(messages, b.String())
+	}
+
+	return messages, nil
+}
+
+// clean reports whether p can be encoded as UTF-16 without being sanitized.
+// The encoder replaces invalid UTF-8 bytes with U+FFFD, so invalid UTF-8 only
+// needs to be sanitized if the policy does something other than replace it.
+func (s Sanitizer) clean(p []byte) bool {
+	for _, c := range p {
+		if 0 == c {
+			return false
+		}
+	}
+
+	return ReplaceInvalidUTF8 == s.InvalidUTF8 || utf8.Valid(p)
+}
+
+// cleanString reports whether message can be encoded as UTF-16 without
+// being sanitized.
+func (s Sanitizer) cleanString(message string) bool {
+	return 0 > strings.IndexByte(message, 0) &&
+		(ReplaceInvalidUTF8 == s.InvalidUTF8 || utf8.ValidString(message))
+}
@@ -0,0 +1,138 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sanitizer", func() {
+	Describe("Sanitize", func() {
+		It("does not change clean messages", func() {
+			Expect(Sanitizer{}.Sanitize("héllo 😀")).To(Equal([]string{"héllo 😀"}))
+		})
+
+		It("escapes NUL characters by default", func() {
+			Expect(Sanitizer{}.Sanitize("a\x00b")).To(Equal([]string{`a\x00b`}))
+		})
+
+		It("replaces invalid UTF-8 bytes by default", func() {
+			Expect(Sanitizer{}.Sanitize("a\xffb")).To(Equal([]string{"a\uFFFDb"}))
+		})
+
+		It("replaces NUL characters", func() {
+			sanitizer := Sanitizer{NUL: ReplaceNUL}
+			Expect(sanitizer.Sanitize("a\x00b")).To(Equal([]string{"a\uFFFDb"}))
+		})
+
+		It("splits messages at NUL characters", func() {
+			sanitizer := Sanitizer{NUL: SplitAtNUL}
+			Expect(sanitizer.Sanitize("a\x00\x00b\x00")).To(Equal([]string{"a", "b"}))
+		})
+
+		It("escapes invalid UTF-8 bytes", func() {
+			sanitizer := Sanitizer{InvalidUTF8: EscapeInvalidUTF8}
+			Expect(sanitizer.Sanitize("a\xe2\x82b")).To(Equal([]string{`a\xe2\x82b`}))
+		})
+
+		It("rejects NUL characters when the policy is strict", func() {
+			_, err := Sanitizer{NUL: StrictNUL}.Sanitize("ab\x00")
+			var invalid *InvalidMessageError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Offset).To(Equal(2))
+			Expect(invalid.Byte).To(BeZero())
+		})
+
+		It("rejects invalid UTF-8 when the policy is strict", func() {
+			_, err := Sanitizer{InvalidUTF8: StrictUTF8}.Sanitize("a\xff")
+			var invalid *InvalidMessageError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Offset).To(Equal(1))
+			Expect(invalid.Byte).To(Equal(byte(0xff)))
+			Expect(err.Error()).To(ContainSubstring("0xff"))
+		})
+	})
+
+	Context("when writing to Process Monitor", func() {
+		var backend *mockProcessMonitor
+		var writer Writer
+
+		BeforeEach(func() {
+			backend = &mockProcessMonitor{}
+			writer = NewWriter(backend)
+		})
+
+		AfterEach(func() {
+			SetSanitizer(Sanitizer{})
+		})
+
+		It("does not lose messages that contain NUL characters", func() {
+			n, err := writer.Write([]byte("len=3 data=\x00\x01\x02"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(14))
+			Expect(backend.message).To(Equal("len=3 data=\\x00\x01\x02"))
+		})
+
+		It("writes each part of a split message", func() {
+			SetSanitizer(Sanitizer{NUL: SplitAtNUL})
+			_, err := writer.WriteString("one\x00two")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend.messages).To(Equal([]string{"one", "two"}))
+		})
+
+		It("returns an error when the message is rejected", func() {
+			SetSanitizer(Sanitizer{NUL: StrictNUL})
+			n, err := writer.WriteString("one\x00two")
+			Expect(n).To(BeZero())
+			Expect(err).To(BeAssignableToTypeOf(&InvalidMessageError{}))
+			Expect(backend.messages).To(BeEmpty())
+		})
+
+		It("sanitizes messages written to a UTF-16 backend", func() {
+			backend := newUTF16Recorder()
+			SetSanitizer(Sanitizer{InvalidUTF8: EscapeInvalidUTF8})
+			_, err := NewWriter(backend).Write([]byte("a\x00b\xff"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend.String()).To(Equal(`a\x00b\xff`))
+		})
+	})
+})
+
+var _ = Describe("NULPolicy", func() {
+	It("has a name", func() {
+		Expect(SplitAtNUL.String()).To(Equal("split"))
+		Expect(NULPolicy(42).String()).To(Equal("NULPolicy(42)"))
+	})
+})
+
+var _ = Describe("InvalidUTF8Policy", func() {
+	It("has a name", func() {
+		Expect(StrictUTF8.String()).To(Equal("strict"))
+		Expect(InvalidUTF8Policy(42).String()).To(Equal("InvalidUTF8Policy(42)"))
+	})
+})
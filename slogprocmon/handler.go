@@ -0,0 +1,388 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package slogprocmon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+)
+
+// Format selects how the attributes of a record are rendered.
+type Format int
+
+const (
+	// Logfmt renders the attributes as key=value pairs after the message.
+	// The keys of attributes in groups are qualified with the group names,
+	// such as "request.id=42".
+	Logfmt Format = iota
+
+	// JSON renders the message and attributes as a JSON object, with groups
+	// as nested objects.
+	JSON
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case Logfmt:
+		return "logfmt"
+	case JSON:
+		return "json"
+	}
+
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// minValueLength is the shortest that a long attribute value is truncated
+// to when a record is fitted within the message size limit.
+const minValueLength = 16
+
+// HandlerOptions configures a Handler.
+type HandlerOptions struct {
+	// Logger writes the records. Its threshold is compared with the slog
+	// level of each record, and its component name and severity prefix are
+	// counted against the message size limit when long attribute values
+	// are shortened. If Logger is nil, records are written to
+	// procmon.ProcessMonitor.
+	Logger *procmon.Logger
+
+	// Level is the minimum slog level of the records that are written, in
+	// addition to the threshold of the Logger. If Level is nil, only the
+	// threshold of the Logger is used.
+	Level slog.Leveler
+
+	// Format selects how the attributes are rendered.
+	Format Format
+}
+
+// Handler is a slog.Handler that writes records to Process Monitor. slog
+// levels are mapped to procmon levels with the same value, so slog.LevelWarn
+// is written as procmon.LevelWarn. Handler is safe for concurrent use.
+type Handler struct {
+	opts     HandlerOptions
+	segments []segment
+}
+
+// segment is a group that was opened by WithGroup or attributes that were
+// added by WithAttrs.
+type segment struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewHandler returns a Handler that uses opts. If opts is nil, the default
+// options are used.
+func NewHandler(opts *HandlerOptions) *Handler {
+	h := &Handler{}
+	if nil != opts {
+		h.opts = *opts
+	}
+
+	if nil == h.opts.Logger {
+		h.opts.Logger = new(procmon.Logger)
+	}
+
+	return h
+}
+
+// Enabled returns true if records with the level would be written. Enabled
+// returns false if the Logger discards every debug message, for example
+// when Process Monitor is not running, so that slog does not build records
+// that would be discarded.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if nil != h.opts.Level && level < h.opts.Level.Level() {
+		return false
+	}
+
+	return h.opts.Logger.Enabled(procmon.Level(level))
+}
+
+// Handle writes the record as a single debug message. If the message is
+// longer than the message size limit, long attribute values are shortened
+// until it fits. Records that are still too long are handled by the current
+// procmon.MessageSizer.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	root := h.tree(r)
+
+	// The Logger adds a component name and severity prefix, which are
+	// included in the limit.
+	limit := procmon.CurrentMessageSizer().MaxLength() -
+		procmon.UTF16Len(h.opts.Logger.Prefix(procmon.Level(r.Level)))
+	maxValue := 0
+	message := h.render(r.Message, root, maxValue)
+	for maxValue = limit / 2; procmon.UTF16Len(message) > limit && minValueLength <= maxValue; maxValue /= 2 {
+		message = h.render(r.Message, root, maxValue)
+	}
+
+	_, err := h.opts.Logger.Log(procmon.Level(r.Level), message)
+	return err
+}
+
+// WithAttrs returns a Handler that adds attrs to each record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if 0 == len(attrs) {
+		return h
+	}
+
+	return h.with(segment{attrs: append([]slog.Attr(nil), attrs...)})
+}
+
+// WithGroup returns a Handler that puts the attributes of each record, and
+// the attributes that are added later, in the group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if "" == name {
+		return h
+	}
+
+	return h.with(segment{group: name})
+}
+
+func (h *Handler) with(s segment) *Handler {
+	segments := make([]segment, len(h.segments), len(h.segments)+1)
+	copy(segments, h.segments)
+	return &Handler{
+		opts:     h.opts,
+		segments: append(segments, s),
+	}
+}
+
+// node is an attribute or group in the tree of attributes of a record.
+type node struct {
+	key      string
+	value    slog.Value
+	group    bool
+	children []*node
+}
+
+// tree returns the attributes of the handler and the record as a tree. The
+// groups that were opened by WithGroup contain the attributes that were
+// added after them and the attributes of the record.
+func (h *Handler) tree(r slog.Record) *node {
+	root := &node{group: true}
+	current := root
+	for _, s := range h.segments {
+		if "" != s.group {
+			g := &node{key: s.group, group: true}
+			current.children = append(current.children, g)
+			current = g
+			continue
+		}
+
+		for _, a := range s.attrs {
+			current.add(a)
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		current.add(a)
+		return true
+	})
+
+	return root
+}
+
+// add adds an attribute to a group, following the rules of slog.Handler:
+// attributes with empty keys are ignored, empty groups are ignored, and the
+// attributes of groups with empty keys are added to the parent group.
+func (n *node) add(a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if slog.KindGroup != a.Value.Kind() {
+		if "" != a.Key {
+			n.children = append(n.children, &node{key: a.Key, value: a.Value})
+		}
+
+		return
+	}
+
+	attrs := a.Value.Group()
+	if 0 == len(attrs) {
+		return
+	}
+
+	g := n
+	if "" != a.Key {
+		g = &node{key: a.Key, group: true}
+		n.children = append(n.children, g)
+	}
+
+	for _, child := range attrs {
+		g.add(child)
+	}
+}
+
+// empty returns true if a group has no attributes.
+func (n *node) empty() bool {
+	if !n.group {
+		return false
+	}
+
+	for _, child := range n.children {
+		if !child.empty() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// render returns the message and attributes in the configured format. If
+// maxValue is not zero, string values are truncated to maxValue bytes.
+func (h *Handler) render(message string, root *node, maxValue int) string {
+	var b bytes.Buffer
+	if JSON == h.opts.Format {
+		b.WriteString(`{"msg":`)
+		writeJSONString(&b, message)
+		if !root.empty() {
+			b.WriteByte(',')
+			writeJSONMembers(&b, root.children, maxValue)
+		}
+
+		b.WriteByte('}')
+		return b.String()
+	}
+
+	b.WriteString(message)
+	writeLogfmt(&b, "", root, maxValue)
+	return b.String()
+}
+
+func writeLogfmt(b *bytes.Buffer, prefix string, n *node, maxValue int) {
+	for _, child := range n.children {
+		if child.group {
+			writeLogfmt(b, prefix+child.key+".", child, maxValue)
+			continue
+		}
+
+		if 0 < b.Len() {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(logfmt.Quote(prefix + child.key))
+		b.WriteByte('=')
+		b.WriteString(logfmt.Quote(truncate(valueString(child.value), maxValue)))
+	}
+}
+
+// writeJSONMembers writes the attributes as the comma-separated members of
+// a JSON object. Empty groups are skipped.
+func writeJSONMembers(b *bytes.Buffer, nodes []*node, maxValue int) {
+	first := true
+	for _, n := range nodes {
+		if n.empty() {
+			continue
+		}
+
+		if !first {
+			b.WriteByte(',')
+		}
+
+		first = false
+		writeJSONString(b, n.key)
+		b.WriteByte(':')
+		if n.group {
+			b.WriteByte('{')
+			writeJSONMembers(b, n.children, maxValue)
+			b.WriteByte('}')
+			continue
+		}
+
+		writeJSONValue(b, n.value, maxValue)
+	}
+}
+
+func writeJSONValue(b *bytes.Buffer, v slog.Value, maxValue int) {
+	switch v.Kind() {
+	case slog.KindBool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case slog.KindInt64:
+		b.WriteString(strconv.FormatInt(v.Int64(), 10))
+	case slog.KindUint64:
+		b.WriteString(strconv.FormatUint(v.Uint64(), 10))
+	case slog.KindFloat64:
+		if p, err := json.Marshal(v.Float64()); nil == err {
+			b.Write(p)
+		} else {
+			writeJSONString(b, valueString(v))
+		}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			writeJSONString(b, truncate(err.Error(), maxValue))
+			return
+		}
+
+		p, err := json.Marshal(v.Any())
+		if nil != err || (0 < maxValue && len(p) > maxValue) {
+			writeJSONString(b, truncate(valueString(v), maxValue))
+			return
+		}
+
+		b.Write(p)
+	default:
+		writeJSONString(b, truncate(valueString(v), maxValue))
+	}
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	p, _ := json.Marshal(s)
+	b.Write(p)
+}
+
+// valueString formats a value as text.
+func valueString(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		return fmt.Sprintf("%+v", v.Any())
+	}
+
+	return v.String()
+}
+
+// truncate shortens s to at most max bytes, ending with "...", without
+// cutting a UTF-8 sequence. If max is zero, s is returned unchanged.
+func truncate(s string, max int) string {
+	if 0 == max || len(s) <= max {
+		return s
+	}
+
+	n := max - 3
+	for 0 < n && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + "..."
+}
@@ -0,0 +1,219 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package slogprocmon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var recorder *procmontest.Recorder
+	var opts *HandlerOptions
+	var logger *slog.Logger
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		opts = &HandlerOptions{
+			Logger: procmon.New(procmon.NewWriter(recorder)),
+		}
+		logger = slog.New(NewHandler(opts))
+	})
+
+	It("writes records with the procmon level of the same value", func() {
+		opts.Logger.SetThreshold(procmon.LevelDebug - 4)
+		logger.Log(context.Background(), slog.LevelDebug-4, "trace")
+		logger.Info("info")
+		logger.Log(context.Background(), slog.LevelWarn+2, "custom")
+		Expect(recorder.Texts()).To(Equal([]string{
+			"DEBUG-4: trace",
+			"INFO: info",
+			"WARN+2: custom",
+		}))
+	})
+
+	It("renders attributes in logfmt format", func() {
+		logger.Info("opened",
+			"path", `C:\data\my file.txt`,
+			"size", 42,
+			"elapsed", 1500*time.Millisecond,
+			"empty", "",
+			slog.Group("request", "id", 7, "ok", true))
+		Expect(recorder.Texts()).To(Equal([]string{
+			`INFO: opened path="C:\\data\\my file.txt" size=42 elapsed=1.5s empty="" request.id=7 request.ok=true`,
+		}))
+	})
+
+	It("renders attributes in JSON format", func() {
+		opts.Format = JSON
+		logger = slog.New(NewHandler(opts))
+		logger.Info("opened",
+			"path", `C:\data\file.txt`,
+			"size", 42,
+			"err", errors.New("denied"),
+			slog.Group("request", "id", 7, slog.Group("user", "name", "jane")))
+
+		text := recorder.Texts()[0]
+		Expect(text).To(HavePrefix(`INFO: {"msg":"opened",`))
+
+		var fields map[string]interface{}
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(text, "INFO: ")), &fields)).To(Succeed())
+		Expect(fields).To(Equal(map[string]interface{}{
+			"msg":  "opened",
+			"path": `C:\data\file.txt`,
+			"size": float64(42),
+			"err":  "denied",
+			"request": map[string]interface{}{
+				"id":   float64(7),
+				"user": map[string]interface{}{"name": "jane"},
+			},
+		}))
+	})
+
+	It("adds attributes from WithAttrs", func() {
+		logger.With("component", "db").Info("connected", "host", "localhost")
+		Expect(recorder.Texts()).To(Equal([]string{
+			"INFO: connected component=db host=localhost",
+		}))
+	})
+
+	It("puts attributes in groups from WithGroup", func() {
+		logger.With("a", 1).WithGroup("g").With("b", 2).WithGroup("h").Info("msg", "c", 3)
+		Expect(recorder.Texts()).To(Equal([]string{"INFO: msg a=1 g.b=2 g.h.c=3"}))
+	})
+
+	It("ignores empty groups and attributes without keys", func() {
+		logger.WithGroup("empty").Info("msg", slog.Group("none"), slog.Attr{})
+		logger.Info("msg", slog.Group("", "inline", 1))
+		Expect(recorder.Texts()).To(Equal([]string{"INFO: msg", "INFO: msg inline=1"}))
+
+		opts.Format = JSON
+		slog.New(NewHandler(opts)).WithGroup("empty").Info("msg")
+		Expect(recorder.Texts()[2]).To(Equal(`INFO: {"msg":"msg"}`))
+	})
+
+	It("resolves LogValuers", func() {
+		logger.Info("msg", "secret", secret("hunter2"))
+		Expect(recorder.Texts()).To(Equal([]string{"INFO: msg secret=REDACTED"}))
+	})
+
+	It("shortens long values to fit within the message size limit", func() {
+		logger.Info("msg",
+			"short", "kept",
+			"long", strings.Repeat("x", 3*procmon.MaxMessageLength))
+
+		texts := recorder.Texts()
+		Expect(texts).To(HaveLen(1))
+		Expect(len(texts[0])).To(BeNumerically("<=", procmon.MaxMessageLength))
+		Expect(texts[0]).To(HavePrefix("INFO: msg short=kept long=xxx"))
+		Expect(texts[0]).To(HaveSuffix("..."))
+	})
+
+	It("leaves room for the component name when shortening values", func() {
+		// The message is long enough that the shortened record would only
+		// fit without the component name.
+		msg := strings.Repeat("m", procmon.MaxMessageLength/2-20)
+		opts.Logger = opts.Logger.Named("slogprocmon-test")
+		slog.New(NewHandler(opts)).Info(msg,
+			"long", strings.Repeat("x", 3*procmon.MaxMessageLength))
+
+		texts := recorder.Texts()
+		Expect(texts).To(HaveLen(1))
+		Expect(len(texts[0])).To(BeNumerically("<=", procmon.MaxMessageLength))
+		Expect(texts[0]).To(HavePrefix("slogprocmon-test: INFO: " + msg + " long=xxx"))
+		Expect(texts[0]).To(HaveSuffix("..."))
+	})
+
+	It("fits values within the limit of the MessageSizer and the prefix of the Logger", func() {
+		defer procmon.SetMessageSizer(procmon.CurrentMessageSizer())
+		procmon.SetMessageSizer(procmon.MessageSizer{Limit: 200})
+		opts.Logger.SetLevelPrefix(func(l procmon.Level) string {
+			return "[" + l.String() + "] " + strings.Repeat("-", 40) + " "
+		})
+
+		// The message is long enough that the shortened record would only
+		// fit without the custom prefix.
+		msg := strings.Repeat("m", 60)
+		logger.Info(msg, "long", strings.Repeat("x", 1000))
+		texts := recorder.Texts()
+		Expect(texts).To(HaveLen(1))
+		Expect(len(texts[0])).To(BeNumerically("<=", 200))
+		Expect(texts[0]).To(HavePrefix("[INFO] ---"))
+		Expect(texts[0]).To(ContainSubstring(msg + " long=xxx"))
+		Expect(texts[0]).To(HaveSuffix("..."))
+	})
+
+	Describe("Enabled", func() {
+		It("uses the threshold of the Logger", func() {
+			opts.Logger.SetThreshold(procmon.LevelWarn)
+			Expect(logger.Enabled(context.Background(), slog.LevelInfo)).To(BeFalse())
+			Expect(logger.Enabled(context.Background(), slog.LevelWarn)).To(BeTrue())
+		})
+
+		It("uses the level option", func() {
+			opts.Level = slog.LevelError
+			logger = slog.New(NewHandler(opts))
+			Expect(logger.Enabled(context.Background(), slog.LevelWarn)).To(BeFalse())
+			Expect(logger.Enabled(context.Background(), slog.LevelError)).To(BeTrue())
+		})
+
+		It("returns false when the output discards debug messages", func() {
+			defer procmon.SetOutput(nil)()
+			Expect(slog.New(NewHandler(nil)).Enabled(context.Background(), slog.LevelError)).
+				To(BeFalse())
+		})
+
+		It("returns true when writing to ProcessMonitor", func() {
+			recorder := procmontest.NewRecorder()
+			defer procmon.SetOutput(procmon.NewWriter(recorder))()
+
+			slog.New(NewHandler(nil)).Info("hello")
+			Expect(recorder.Texts()).To(Equal([]string{"INFO: hello"}))
+		})
+	})
+})
+
+var _ = Describe("Format", func() {
+	It("has a name", func() {
+		Expect(JSON.String()).To(Equal("json"))
+		Expect(Format(7).String()).To(Equal("Format(7)"))
+	})
+})
+
+// secret is a LogValuer that hides its value.
+type secret string
+
+func (secret) LogValue() slog.Value {
+	return slog.StringValue("REDACTED")
+}
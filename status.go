@@ -0,0 +1,198 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mfcollins3/go-debugger"
+)
+
+// StatusReport describes the state of the connection that ProcessMonitor
+// writes to. A StatusReport is returned by Status.
+type StatusReport struct {
+	// Active is true if debug messages are being written to a backend.
+	Active bool
+
+	// Backend is the specification of the backends that ProcessMonitor
+	// writes to, such as "procmon" or "procmon,stderr".
+	Backend string
+
+	// DetectedAt is the time that the backends were opened. DetectedAt is
+	// the zero time if the backends are not open.
+	DetectedAt time.Time
+
+	// OpenError is the error that was returned by the last attempt to open
+	// the backends, or nil if the last attempt succeeded.
+	OpenError error
+
+	// WriteError is the last error that was returned when writing a debug
+	// message, or nil if no write has failed.
+	WriteError error
+
+	// Writes is the number of debug messages that were written successfully.
+	Writes uint64
+
+	// FailedWrites is the number of debug messages that could not be
+	// written.
+	FailedWrites uint64
+
+	// Redactions counts the work done by the Redactor that was installed
+	// using SetRedactor.
+	Redactions RedactionStats
+}
+
+// String returns a one-line description of the status that is suitable for
+// displaying in an about box or in the output of a --version flag.
+func (s StatusReport) String() string {
+	switch {
+	case s.Active:
+		return fmt.Sprintf("Process Monitor logging: active (%s)", s.Backend)
+	case nil != s.OpenError:
+		return fmt.Sprintf("Process Monitor logging: inactive (%v)", s.OpenError)
+	}
+
+	return "Process Monitor logging: inactive"
+}
+
+// Status returns the current state of the connection that ProcessMonitor
+// writes to.
+func Status() StatusReport {
+	connectionMu.Lock()
+	c := current
+	connectionMu.Unlock()
+
+	var report StatusReport
+	if nil != c {
+		report = c.status()
+	}
+
+	if r := CurrentRedactor(); nil != r {
+		report.Redactions = r.Stats()
+	}
+
+	return report
+}
+
+// connectionStats records the outcome of opening and writing to the backends
+// of a connection.
+type connectionStats struct {
+	mu           sync.Mutex
+	detectedAt   time.Time
+	openError    error
+	writeError   error
+	writes       uint64
+	failedWrites uint64
+}
+
+func (s *connectionStats) opened(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.detectedAt = time.Now()
+	s.openError = err
+}
+
+func (s *connectionStats) openFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.openError = err
+}
+
+func (s *connectionStats) closed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.detectedAt = time.Time{}
+}
+
+func (s *connectionStats) wrote(err error) {
+	if nil == err {
+		atomic.AddUint64(&s.writes, 1)
+		return
+	}
+
+	atomic.AddUint64(&s.failedWrites, 1)
+	s.mu.Lock()
+	s.writeError = err
+	s.mu.Unlock()
+}
+
+func (s *connectionStats) report() StatusReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return StatusReport{
+		DetectedAt:   s.detectedAt,
+		OpenError:    s.openError,
+		WriteError:   s.writeError,
+		Writes:       atomic.LoadUint64(&s.writes),
+		FailedWrites: atomic.LoadUint64(&s.failedWrites),
+	}
+}
+
+// diagnosticsWriter holds the io.Writer that receives the package's internal
+// diagnostic messages.
+type diagnosticsWriter struct {
+	w io.Writer
+}
+
+var diagnostics atomic.Value
+
+// SetDiagnostics sets the io.Writer that receives diagnostic messages
+// describing why the backends could not be opened or written to. By default,
+// diagnostic messages are written to the debugger console and can be viewed
+// using DebugView on Microsoft Windows. Passing nil discards the diagnostic
+// messages.
+func SetDiagnostics(w io.Writer) {
+	if nil == w {
+		w = io.Discard
+	}
+
+	diagnostics.Store(diagnosticsWriter{w})
+}
+
+// diagnosef formats a diagnostic message and writes it to the diagnostics
+// writer. Each message ends with a newline so that consecutive messages are
+// on separate lines when the diagnostics writer is a stream such as a file.
+func diagnosef(format string, a ...interface{}) {
+	w := io.Writer(debugger.Console)
+	if d, ok := diagnostics.Load().(diagnosticsWriter); ok {
+		w = d.w
+	}
+
+	message := fmt.Sprintf(format, a...)
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
+	io.WriteString(w, message)
+}
@@ -0,0 +1,133 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/mfcollins3/go-debugger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	var original Writer
+	var opener *fakeOpener
+
+	BeforeEach(func() {
+		original = Output()
+		opener = &fakeOpener{}
+	})
+
+	AfterEach(func() {
+		Close()
+		SetOutput(original)
+	})
+
+	It("reports that logging is inactive when Open has not been called", func() {
+		Close()
+		Expect(Status().Active).To(BeFalse())
+		Expect(Status().String()).To(Equal("Process Monitor logging: inactive"))
+	})
+
+	It("reports the active backend", func() {
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		status := Status()
+		Expect(status.Active).To(BeTrue())
+		Expect(status.Backend).To(Equal("mock"))
+		Expect(status.DetectedAt.IsZero()).To(BeFalse())
+		Expect(status.String()).
+			To(Equal("Process Monitor logging: active (mock)"))
+	})
+
+	It("reports the error that occurred when opening the backend", func() {
+		opener.SetFail(true)
+		Open(Options{Backend: "mock", Opener: opener.Open})
+		status := Status()
+		Expect(status.Active).To(BeFalse())
+		Expect(status.OpenError).To(MatchError("device not found"))
+		Expect(status.String()).
+			To(Equal("Process Monitor logging: inactive (device not found)"))
+	})
+
+	It("counts successful and failed writes", func() {
+		fail := false
+		Open(Options{
+			Backend: "mock",
+			Opener: func(spec string) (Backend, error) {
+				return backendFunc(func(message string) error {
+					if fail {
+						return errors.New("write failed")
+					}
+
+					return nil
+				}), nil
+			},
+		})
+
+		io.WriteString(ProcessMonitor, "one")
+		io.WriteString(ProcessMonitor, "two")
+		fail = true
+		io.WriteString(ProcessMonitor, "three")
+
+		status := Status()
+		Expect(status.Writes).To(Equal(uint64(2)))
+		Expect(status.FailedWrites).To(Equal(uint64(1)))
+		Expect(status.WriteError).To(MatchError("write failed"))
+	})
+})
+
+var _ = Describe("SetDiagnostics", func() {
+	var original Writer
+
+	BeforeEach(func() {
+		original = Output()
+	})
+
+	AfterEach(func() {
+		Close()
+		SetOutput(original)
+		SetDiagnostics(debugger.Console)
+	})
+
+	It("receives the internal diagnostic messages", func() {
+		var buf bytes.Buffer
+		SetDiagnostics(&buf)
+		Open(Options{Backend: "mock", Opener: (&fakeOpener{}).Open})
+		Expect(buf.String()).
+			To(ContainSubstring("Process Monitor debug logging is enabled"))
+	})
+
+	It("writes each diagnostic message on its own line", func() {
+		var buf bytes.Buffer
+		SetDiagnostics(&buf)
+		diagnosef("first: %d", 1)
+		diagnosef("second\n")
+		Expect(buf.String()).To(Equal("first: 1\nsecond\n"))
+	})
+})
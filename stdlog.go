@@ -0,0 +1,70 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"io"
+	"log"
+)
+
+// NewLogger returns a log.Logger that writes to ProcessMonitor. The prefix
+// and flags are passed to log.New. Output that contains several lines is
+// written as one debug message per line.
+func NewLogger(prefix string, flags int) *log.Logger {
+	// log.Logger ends every write with a newline, so the LineWriter never
+	// holds a partial line and does not need an idle timeout.
+	return log.New(NewLineWriter(outputWriter{}), prefix, flags)
+}
+
+// RedirectStdLog makes the standard logger in the log package write to
+// ProcessMonitor in addition to its current destination. RedirectStdLog
+// returns a function that restores the original destination.
+//
+//	undo := procmon.RedirectStdLog()
+//	defer undo()
+func RedirectStdLog() (undo func()) {
+	original := log.Writer()
+	log.SetOutput(&stdLogWriter{
+		original: original,
+		procmon:  NewLineWriter(outputWriter{}),
+	})
+
+	return func() {
+		log.SetOutput(original)
+	}
+}
+
+// stdLogWriter writes the output of the standard logger to its original
+// destination and to ProcessMonitor. Errors from ProcessMonitor are
+// ignored so that the standard logger behaves as it did before.
+type stdLogWriter struct {
+	original io.Writer
+	procmon  io.Writer
+}
+
+func (w *stdLogWriter) Write(p []byte) (n int, err error) {
+	w.procmon.Write(p)
+	return w.original.Write(p)
+}
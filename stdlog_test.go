@@ -0,0 +1,109 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewLogger", func() {
+	var recorder *recordingWriter
+
+	BeforeEach(func() {
+		recorder = &recordingWriter{}
+	})
+
+	It("writes to ProcessMonitor", func() {
+		defer SetOutput(recorder)()
+
+		logger := NewLogger("app: ", 0)
+		logger.Printf("started %d workers", 4)
+		Expect(recorder.Messages()).To(Equal([]string{"app: started 4 workers"}))
+	})
+
+	It("writes one debug message per line", func() {
+		defer SetOutput(recorder)()
+
+		NewLogger("", 0).Print("first\r\nsecond\n\nthird\n")
+		Expect(recorder.Messages()).To(Equal([]string{"first", "second", "third"}))
+	})
+
+	It("keeps the flags of the log package", func() {
+		defer SetOutput(recorder)()
+
+		NewLogger("", log.Lshortfile).Print("hello")
+		Expect(recorder.Messages()).To(HaveLen(1))
+		Expect(recorder.Messages()[0]).To(MatchRegexp(`^stdlog_test\.go:\d+: hello$`))
+	})
+})
+
+var _ = Describe("RedirectStdLog", func() {
+	var recorder *recordingWriter
+	var original bytes.Buffer
+	var flags int
+	var previous io.Writer
+
+	BeforeEach(func() {
+		recorder = &recordingWriter{}
+		original.Reset()
+		flags = log.Flags()
+		previous = log.Writer()
+		log.SetFlags(0)
+		log.SetOutput(&original)
+	})
+
+	AfterEach(func() {
+		log.SetFlags(flags)
+		log.SetOutput(previous)
+	})
+
+	It("writes the standard logger to ProcessMonitor and the original destination", func() {
+		defer SetOutput(recorder)()
+
+		undo := RedirectStdLog()
+		log.Print("one\ntwo")
+		Expect(recorder.Messages()).To(Equal([]string{"one", "two"}))
+		Expect(original.String()).To(Equal("one\ntwo\n"))
+
+		undo()
+		log.Print("three")
+		Expect(recorder.Messages()).To(HaveLen(2))
+		Expect(original.String()).To(Equal("one\ntwo\nthree\n"))
+	})
+
+	It("writes to the original destination when ProcessMonitor fails", func() {
+		defer SetOutput(&recordingWriter{err: errors.New("write failed")})()
+
+		defer RedirectStdLog()()
+		log.Print("one")
+		Expect(original.String()).To(Equal("one\n"))
+	})
+})
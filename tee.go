@@ -0,0 +1,127 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sink is a destination for the debug messages that are written to a Tee.
+type Sink struct {
+	// Writer receives the debug messages that pass the sink's filters.
+	Writer Writer
+
+	// Level is the minimum level of the debug messages that are written to
+	// the sink. The zero value is LevelInfo, so set Level to LevelDebug to
+	// receive debug messages.
+	Level Level
+
+	// Components limits the sink to debug messages from the listed
+	// components and their child components. If Components is empty, debug
+	// messages from every component, and messages that do not have a
+	// component, are written to the sink.
+	Components []string
+}
+
+// accepts returns true if e passes the sink's level and component filters.
+func (s *Sink) accepts(e *Entry) bool {
+	if e.Level < s.Level {
+		return false
+	}
+
+	if 0 == len(s.Components) {
+		return true
+	}
+
+	for _, c := range s.Components {
+		if e.Component == c || strings.HasPrefix(e.Component, c+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TeeWriter writes each debug message to every sink whose filters accept
+// it. TeeWriter is returned by Tee.
+type TeeWriter struct {
+	sinks []Sink
+}
+
+// Tee returns a Writer that writes each debug message to several sinks, such
+// as ProcessMonitor, the debugger console, and a file. A sink that returns
+// an error does not prevent the message from being written to the other
+// sinks. The errors from all of the sinks are joined and returned.
+func Tee(sinks ...Sink) *TeeWriter {
+	return &TeeWriter{sinks: append([]Sink(nil), sinks...)}
+}
+
+// Write writes p to the sinks as a debug message with LevelInfo.
+func (t *TeeWriter) Write(p []byte) (n int, err error) {
+	if err = t.WriteEntry(newEntry(string(p))); nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteString writes s to the sinks as a debug message with LevelInfo.
+func (t *TeeWriter) WriteString(s string) (n int, err error) {
+	if err = t.WriteEntry(newEntry(s)); nil != err {
+		return 0, err
+	}
+
+	return len(s), nil
+}
+
+// WriteEntry writes e to every sink whose filters accept it.
+func (t *TeeWriter) WriteEntry(e *Entry) error {
+	var errs []error
+	for i := range t.sinks {
+		sink := &t.sinks[i]
+		if !sink.accepts(e) {
+			continue
+		}
+
+		c := *e
+		if err := WriteEntry(sink.Writer, &c); nil != err {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// discards returns true if every sink discards every debug message.
+func (t *TeeWriter) discards() bool {
+	for _, s := range t.sinks {
+		if !discards(s.Writer) {
+			return false
+		}
+	}
+
+	return true
+}
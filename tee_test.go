@@ -0,0 +1,94 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package procmon
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tee", func() {
+	var first, second *entryRecorder
+
+	BeforeEach(func() {
+		first = &entryRecorder{}
+		second = &entryRecorder{}
+	})
+
+	It("writes each message to every sink", func() {
+		tee := Tee(Sink{Writer: first}, Sink{Writer: second})
+		n, err := io.WriteString(tee, "Hello, World!")
+		Expect(n).To(Equal(13))
+		Expect(err).To(BeNil())
+		Expect(first.Messages()).To(Equal([]string{"Hello, World!"}))
+		Expect(second.Messages()).To(Equal([]string{"Hello, World!"}))
+	})
+
+	It("filters messages by level", func() {
+		logger := New(Tee(
+			Sink{Writer: first, Level: LevelDebug},
+			Sink{Writer: second, Level: LevelWarn},
+		))
+		logger.Debugf("debug")
+		logger.Errorf("error")
+		Expect(first.Messages()).To(Equal([]string{"DEBUG: debug", "ERROR: error"}))
+		Expect(second.Messages()).To(Equal([]string{"ERROR: error"}))
+	})
+
+	It("filters messages by component", func() {
+		logger := New(Tee(
+			Sink{Writer: first},
+			Sink{Writer: second, Components: []string{"storage"}},
+		))
+		logger.Print("unnamed")
+		logger.Named("storage.cache").Print("cache")
+		logger.Named("storagex").Print("other")
+		Expect(first.Messages()).To(HaveLen(3))
+		Expect(second.Messages()).To(Equal([]string{"storage.cache: cache"}))
+	})
+
+	It("writes to the other sinks when a sink fails", func() {
+		first.err = errors.New("first failed")
+		third := &entryRecorder{}
+		third.err = errors.New("third failed")
+		tee := Tee(Sink{Writer: first}, Sink{Writer: second}, Sink{Writer: third})
+
+		_, err := io.WriteString(tee, "Hello, World!")
+		Expect(err).To(MatchError(ContainSubstring("first failed")))
+		Expect(err).To(MatchError(ContainSubstring("third failed")))
+		Expect(second.Messages()).To(Equal([]string{"Hello, World!"}))
+	})
+
+	It("discards messages when every sink discards them", func() {
+		tee := Tee(Sink{Writer: &nullProcessMonitor{}})
+		Expect(New(tee).Enabled(LevelError)).To(BeFalse())
+
+		tee = Tee(Sink{Writer: &nullProcessMonitor{}}, Sink{Writer: first})
+		Expect(New(tee).Enabled(LevelError)).To(BeTrue())
+	})
+})
@@ -0,0 +1,221 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package zapprocmon
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+	"go.uber.org/zap/zapcore"
+)
+
+// CoreOptions configures a Core.
+type CoreOptions struct {
+	// Logger writes the entries. Entries from a named zap logger are
+	// written by the Logger for the component of the same name below
+	// Logger, so their threshold can be set separately. If Logger is nil,
+	// entries are written to procmon.ProcessMonitor.
+	Logger *procmon.Logger
+
+	// Level decides which zap levels are written, in addition to the
+	// threshold of the Logger. If Level is nil, only the threshold of the
+	// Logger is used.
+	Level zapcore.LevelEnabler
+}
+
+// Core is a zapcore.Core that writes log entries to Process Monitor. Core is
+// safe for concurrent use.
+type Core struct {
+	opts   CoreOptions
+	fields []zapcore.Field
+	named  *sync.Map
+}
+
+// NewCore returns a Core that uses opts. If opts is nil, the default options
+// are used.
+func NewCore(opts *CoreOptions) *Core {
+	c := &Core{named: new(sync.Map)}
+	if nil != opts {
+		c.opts = *opts
+	}
+
+	if nil == c.opts.Logger {
+		c.opts.Logger = new(procmon.Logger)
+	}
+
+	return c
+}
+
+// Enabled returns true if entries with the level would be written. Enabled
+// returns false if the Logger discards every debug message, for example
+// when Process Monitor is not running.
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.enabled(c.opts.Logger, level)
+}
+
+// With returns a Core that adds fields to each entry.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	if 0 == len(fields) {
+		return c
+	}
+
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(append(clone.fields, c.fields...), fields...)
+	return &clone
+}
+
+// Check adds the Core to the checked entry if the entry would be written.
+// The threshold of the procmon component that is named after the zap logger
+// is used.
+func (c *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.enabled(c.logger(entry.LoggerName), entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write writes the entry as a single debug message.
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	var b bytes.Buffer
+	b.WriteString(entry.Message)
+
+	var errs []error
+	prefix := ""
+	for _, f := range append(c.fields[:len(c.fields):len(c.fields)], fields...) {
+		switch {
+		case zapcore.SkipType == f.Type:
+			continue
+		case zapcore.NamespaceType == f.Type:
+			prefix += f.Key + "."
+			continue
+		case zapcore.ErrorType == f.Type && logfmt.ErrorKey == f.Key:
+			if err, ok := f.Interface.(error); ok {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		appendField(&b, prefix, f)
+	}
+
+	for _, err := range errs {
+		logfmt.AppendError(&b, err)
+	}
+
+	if entry.Caller.Defined {
+		logfmt.AppendCaller(&b, entry.Caller.File, entry.Caller.Line)
+	}
+
+	_, err := c.logger(entry.LoggerName).Log(Level(entry.Level), b.String())
+	return err
+}
+
+// Sync does nothing because debug messages are not buffered.
+func (c *Core) Sync() error {
+	return nil
+}
+
+func (c *Core) enabled(logger *procmon.Logger, level zapcore.Level) bool {
+	if nil != c.opts.Level && !c.opts.Level.Enabled(level) {
+		return false
+	}
+
+	return logger.Enabled(Level(level))
+}
+
+// logger returns the Logger for the component that is named after a zap
+// logger. The Loggers are cached because naming a component takes a lock.
+func (c *Core) logger(name string) *procmon.Logger {
+	if "" == name {
+		return c.opts.Logger
+	}
+
+	if l, ok := c.named.Load(name); ok {
+		return l.(*procmon.Logger)
+	}
+
+	l, _ := c.named.LoadOrStore(name, c.opts.Logger.Named(name))
+	return l.(*procmon.Logger)
+}
+
+// appendField writes the key=value pairs that a field adds. Objects and
+// arrays are written as JSON.
+func appendField(b *bytes.Buffer, prefix string, f zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		logfmt.Append(b, prefix+key, value(enc.Fields[key]))
+	}
+}
+
+func value(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if p, err := json.Marshal(v); nil == err {
+			return string(p)
+		}
+	}
+
+	return logfmt.Value(v)
+}
+
+// Level returns the procmon level that a zap level is written with.
+// zapcore.DPanicLevel is written as procmon.LevelError+2, between errors
+// and panics, zapcore.PanicLevel as procmon.LevelError+4, and
+// zapcore.FatalLevel as procmon.LevelError+8. Custom levels below
+// zapcore.DebugLevel are written 4 procmon levels apart below
+// procmon.LevelDebug. The other levels are written with the procmon level
+// of the same name.
+func Level(l zapcore.Level) procmon.Level {
+	switch {
+	case zapcore.DebugLevel >= l:
+		return procmon.LevelDebug - 4*procmon.Level(zapcore.DebugLevel-l)
+	case zapcore.InfoLevel == l:
+		return procmon.LevelInfo
+	case zapcore.WarnLevel == l:
+		return procmon.LevelWarn
+	case zapcore.ErrorLevel == l:
+		return procmon.LevelError
+	case zapcore.DPanicLevel == l:
+		return (procmon.LevelError + logfmt.LevelPanic) / 2
+	case zapcore.PanicLevel == l:
+		return logfmt.LevelPanic
+	}
+
+	return logfmt.LevelFatal
+}
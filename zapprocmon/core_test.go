@@ -0,0 +1,145 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package zapprocmon
+
+import (
+	"errors"
+	"time"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ = Describe("Core", func() {
+	var recorder *procmontest.Recorder
+	var opts *CoreOptions
+	var logger *zap.Logger
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		opts = &CoreOptions{
+			Logger: procmon.New(procmon.NewWriter(recorder)),
+		}
+		logger = zap.New(NewCore(opts))
+	})
+
+	It("writes the entry before zap panics", func() {
+		logger.DPanic("inconsistent", zap.Int("shard", 3))
+		Expect(func() { logger.Panic("corrupted") }).To(Panic())
+		Expect(recorder.Texts()).To(Equal([]string{
+			"ERROR+2: inconsistent shard=3",
+			"ERROR+4: corrupted",
+		}))
+	})
+
+	It("writes fields in the order that they were added", func() {
+		logger.Info("reloaded",
+			zap.Int("keys", 12),
+			zap.Duration("took", 1500*time.Millisecond),
+			zap.String("source", "redis://cache:6379"),
+			zap.Bool("partial", false),
+			zap.Strings("regions", []string{"east", "west"}),
+			zap.Namespace("stats"),
+			zap.Int("hits", 7))
+		Expect(recorder.Texts()).To(Equal([]string{
+			`INFO: reloaded keys=12 took=1.5s source=redis://cache:6379 ` +
+				`partial=false regions="[\"east\",\"west\"]" stats.hits=7`,
+		}))
+	})
+
+	It("adds fields from With", func() {
+		logger.With(zap.String("region", "east")).Info("reloaded", zap.Int("keys", 12))
+		Expect(recorder.Texts()).To(Equal([]string{
+			"INFO: reloaded region=east keys=12",
+		}))
+	})
+
+	It("writes the field that zap.Error adds after the other fields", func() {
+		logger.With(zap.Error(errors.New("connection refused"))).
+			Error("reload failed", zap.String("region", "east"))
+		logger.Warn("retrying", zap.NamedError("cause", errors.New("timeout")))
+		Expect(recorder.Texts()).To(Equal([]string{
+			`ERROR: reload failed region=east error="connection refused"`,
+			"WARN: retrying cause=timeout",
+		}))
+	})
+
+	It("writes the caller last when the logger adds callers", func() {
+		logger = zap.New(NewCore(opts), zap.AddCaller())
+		logger.Warn("reload failed", zap.Error(errors.New("timeout")))
+		Expect(recorder.Texts()).To(HaveLen(1))
+		Expect(recorder.Texts()[0]).To(MatchRegexp(
+			`^WARN: reload failed error=timeout caller=zapprocmon/core_test\.go:\d+$`))
+	})
+
+	It("uses the level option", func() {
+		opts.Level = zapcore.WarnLevel
+		logger = zap.New(NewCore(opts))
+		logger.Info("info")
+		logger.Warn("warn")
+		Expect(recorder.Texts()).To(Equal([]string{"WARN: warn"}))
+	})
+
+	It("uses the names of zap loggers as component names", func() {
+		named := logger.Named("zapprocmon-test").Named("cache")
+		named.Warn("slow")
+		procmon.Named("zapprocmon-test.cache").SetThreshold(procmon.LevelError)
+		defer procmon.Named("zapprocmon-test.cache").ResetThreshold()
+
+		named.Warn("slower")
+		Expect(recorder.Texts()).To(Equal([]string{"zapprocmon-test.cache: WARN: slow"}))
+	})
+
+	Describe("Enabled", func() {
+		It("uses the threshold of the Logger", func() {
+			opts.Logger.SetThreshold(procmon.LevelWarn)
+			Expect(NewCore(opts).Enabled(zapcore.InfoLevel)).To(BeFalse())
+			Expect(NewCore(opts).Enabled(zapcore.WarnLevel)).To(BeTrue())
+		})
+
+		It("returns false when the output discards debug messages", func() {
+			defer procmon.SetOutput(nil)()
+			Expect(NewCore(nil).Enabled(zapcore.ErrorLevel)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Level", func() {
+	It("maps the levels that procmon does not name", func() {
+		Expect(Level(zapcore.DPanicLevel)).To(Equal(procmon.LevelError + 2))
+		Expect(Level(zapcore.PanicLevel)).To(Equal(logfmt.LevelPanic))
+		Expect(Level(zapcore.FatalLevel)).To(Equal(logfmt.LevelFatal))
+	})
+
+	It("spaces custom levels below zapcore.DebugLevel 4 procmon levels apart", func() {
+		Expect(Level(zapcore.DebugLevel - 1)).To(Equal(logfmt.LevelTrace))
+		Expect(Level(zapcore.DebugLevel - 2)).To(Equal(procmon.LevelDebug - 8))
+	})
+})
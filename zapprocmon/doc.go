@@ -0,0 +1,44 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+/*
+Package zapprocmon provides a zapcore.Core that mirrors log entries to
+Process Monitor.
+
+	core := zapcore.NewTee(core, zapprocmon.NewCore(nil))
+	logger := zap.New(core, zap.AddCaller()).Named("cache")
+	logger.Warn("reload failed", zap.Int("keys", n), zap.Error(err))
+
+Each entry is written as a single debug message with a severity prefix and
+its fields in logfmt format, in the order that they were added. Fields in a
+zap.Namespace are prefixed with the namespace, and objects and arrays are
+written as JSON. The field that zap.Error adds and the caller, if the
+logger has zap.AddCaller, are written last:
+
+	cache: WARN: reload failed keys=12 error=timeout caller=app/load.go:87
+
+The names of zap loggers are used as procmon component names, so the
+threshold of a named zap logger can be changed using procmon.Named.
+*/
+package zapprocmon
@@ -0,0 +1,45 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+/*
+Package zerologprocmon provides a Writer that turns the JSON events that are
+written by zerolog into readable debug messages for Process Monitor.
+
+	w := zerologprocmon.NewWriter(nil)
+	logger := zerolog.New(w).With().Caller().Logger()
+	logger.Warn().Str("method", "GET").Int("status", 503).Msg("no upstream")
+
+Each event is written as a single debug message with a severity prefix and
+its fields in logfmt format, in the order that they appear in the JSON.
+Arrays and objects are written as compact JSON, the time is dropped because
+Process Monitor records its own, and the error and the caller are moved to
+the end:
+
+	WARN: no upstream method=GET status=503 caller=api/proxy.go:58
+
+The Writer does not import zerolog, so it can be used with any logger that
+writes one JSON object per line. The keys that it looks for can be changed
+using WriterOptions.
+*/
+package zerologprocmon
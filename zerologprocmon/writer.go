@@ -0,0 +1,261 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package zerologprocmon
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+)
+
+// WriterOptions configures a Writer. The keys default to the field names
+// that zerolog uses by default.
+type WriterOptions struct {
+	// Logger writes the events. The threshold of Logger applies to events
+	// with a level; events without a level and lines that are not JSON
+	// objects are always written. If Logger is nil, events are written to
+	// procmon.ProcessMonitor.
+	Logger *procmon.Logger
+
+	// LevelKey is the key of the level of an event. The default is "level".
+	LevelKey string
+
+	// MessageKey is the key of the message of an event. The default is
+	// "message".
+	MessageKey string
+
+	// ErrorKey is the key of the error of an event. The default is "error".
+	ErrorKey string
+
+	// CallerKey is the key of the caller of an event. The default is
+	// "caller".
+	CallerKey string
+
+	// TimeKey is the key of the time of an event. The time is not written
+	// because Process Monitor records the time of each debug message. The
+	// default is "time".
+	TimeKey string
+}
+
+// Writer is an io.Writer that writes zerolog events to Process Monitor.
+// Each line that is written to the Writer is parsed as an event. Lines that
+// are not JSON objects are written unchanged. Writer is safe for
+// concurrent use.
+type Writer struct {
+	opts WriterOptions
+}
+
+// NewWriter returns a Writer that uses opts. If opts is nil, the default
+// options are used.
+func NewWriter(opts *WriterOptions) *Writer {
+	w := &Writer{}
+	if nil != opts {
+		w.opts = *opts
+	}
+
+	if nil == w.opts.Logger {
+		w.opts.Logger = new(procmon.Logger)
+	}
+
+	setDefault(&w.opts.LevelKey, "level")
+	setDefault(&w.opts.MessageKey, "message")
+	setDefault(&w.opts.ErrorKey, "error")
+	setDefault(&w.opts.CallerKey, "caller")
+	setDefault(&w.opts.TimeKey, "time")
+	return w
+}
+
+func setDefault(key *string, value string) {
+	if "" == *key {
+		*key = value
+	}
+}
+
+// Write writes each line of p as a debug message. Events with a level are
+// written with the procmon level of the zerolog level and are subject to
+// the threshold of the Logger. Events without a level are written without
+// a severity prefix.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if 0 == len(line) {
+			continue
+		}
+
+		if e := w.writeLine(line); nil != e && nil == err {
+			err = e
+		}
+	}
+
+	if nil != err {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// event is a parsed zerolog event.
+type event struct {
+	level   string
+	message string
+	err     string
+	caller  string
+	fields  []field
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	e, ok := w.parse(line)
+	if !ok {
+		_, err := w.opts.Logger.WriteString(string(line))
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(e.message)
+	for _, f := range e.fields {
+		logfmt.Append(&b, f.key, f.value)
+	}
+
+	if "" != e.err {
+		logfmt.Append(&b, logfmt.ErrorKey, e.err)
+	}
+
+	if "" != e.caller {
+		appendCaller(&b, e.caller)
+	}
+
+	level, ok := Level(e.level)
+	if !ok {
+		_, err := w.opts.Logger.WriteString(b.String())
+		return err
+	}
+
+	_, err := w.opts.Logger.Log(level, b.String())
+	return err
+}
+
+// parse parses a line as a JSON object, keeping the order of its fields.
+func (w *Writer) parse(line []byte) (*event, bool) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if t, err := dec.Token(); nil != err || json.Delim('{') != t {
+		return nil, false
+	}
+
+	e := &event{}
+	for dec.More() {
+		t, err := dec.Token()
+		if nil != err {
+			return nil, false
+		}
+
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); nil != err {
+			return nil, false
+		}
+
+		v := text(raw)
+		switch key {
+		case w.opts.LevelKey:
+			e.level = v
+		case w.opts.MessageKey:
+			e.message = v
+		case w.opts.ErrorKey:
+			e.err = v
+		case w.opts.CallerKey:
+			e.caller = v
+		case w.opts.TimeKey:
+		default:
+			e.fields = append(e.fields, field{key: key, value: v})
+		}
+	}
+
+	if _, err := dec.Token(); nil != err {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// text returns a JSON string without its quotes, and other JSON values as
+// compact JSON.
+func text(raw json.RawMessage) string {
+	var s string
+	if 0 < len(raw) && '"' == raw[0] && nil == json.Unmarshal(raw, &s) {
+		return s
+	}
+
+	var b bytes.Buffer
+	if nil != json.Compact(&b, raw) {
+		return string(raw)
+	}
+
+	return b.String()
+}
+
+// appendCaller writes the caller of an event, which zerolog formats as
+// "file:line", in the format of the other adapters.
+func appendCaller(b *bytes.Buffer, caller string) {
+	if i := strings.LastIndexByte(caller, ':'); 0 <= i {
+		if line, err := strconv.Atoi(caller[i+1:]); nil == err {
+			logfmt.AppendCaller(b, caller[:i], line)
+			return
+		}
+	}
+
+	logfmt.Append(b, logfmt.CallerKey, caller)
+}
+
+// Level returns the procmon level that a zerolog level is written with.
+// "trace" is written as procmon.LevelDebug-4, "panic" as
+// procmon.LevelError+4, and "fatal" as procmon.LevelError+8, like the trace,
+// panic, and fatal levels of the other adapters. The other levels are
+// parsed by procmon.ParseLevel. Level returns false for events without a
+// level and for unknown levels.
+func Level(name string) (procmon.Level, bool) {
+	switch name {
+	case "trace":
+		return logfmt.LevelTrace, true
+	case "panic":
+		return logfmt.LevelPanic, true
+	case "fatal":
+		return logfmt.LevelFatal, true
+	case "":
+		return 0, false
+	}
+
+	l, err := procmon.ParseLevel(name)
+	return l, nil == err
+}
@@ -0,0 +1,137 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2015 Michael F. Collins, III
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including but without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is furnished
+to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package zerologprocmon
+
+import (
+	"io"
+
+	procmon "github.com/mfcollins3/go-procmon"
+	"github.com/mfcollins3/go-procmon/internal/logfmt"
+	"github.com/mfcollins3/go-procmon/procmontest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writer", func() {
+	var recorder *procmontest.Recorder
+	var opts *WriterOptions
+	var w io.Writer
+
+	write := func(lines ...string) {
+		for _, line := range lines {
+			n, err := w.Write([]byte(line + "\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(len(line) + 1))
+		}
+	}
+
+	BeforeEach(func() {
+		recorder = procmontest.NewRecorder()
+		opts = &WriterOptions{
+			Logger: procmon.New(procmon.NewWriter(recorder)),
+		}
+		w = NewWriter(opts)
+	})
+
+	It("writes events with the procmon level of the zerolog level", func() {
+		opts.Logger.SetThreshold(logfmt.LevelTrace)
+		write(
+			`{"level":"trace","message":"dialing"}`,
+			`{"level":"warn","message":"slow"}`,
+			`{"level":"fatal","message":"no listener"}`)
+		Expect(recorder.Texts()).To(Equal([]string{
+			"DEBUG-4: dialing",
+			"WARN: slow",
+			"ERROR+8: no listener",
+		}))
+	})
+
+	It("writes fields in the order that they appear in the JSON", func() {
+		write(`{"level":"info","method":"GET","path":"/api/users?name=jane doe",` +
+			`"status":200,"took":0.25,"cached":false,"user":null,` +
+			`"tags":["api","v2"],"client":{"ip":"10.0.0.7"},` +
+			`"time":"2016-01-02T03:04:05Z","message":"handled"}`)
+		Expect(recorder.Texts()).To(Equal([]string{
+			`INFO: handled method=GET path="/api/users?name=jane doe" status=200 took=0.25 ` +
+				`cached=false user=null tags="[\"api\",\"v2\"]" client="{\"ip\":\"10.0.0.7\"}"`,
+		}))
+	})
+
+	It("moves the error and the caller to the end", func() {
+		write(`{"level":"error","error":"connection refused","caller":"/src/app/api/proxy.go:58",` +
+			`"method":"GET","message":"no upstream"}`)
+		Expect(recorder.Texts()).To(Equal([]string{
+			`ERROR: no upstream method=GET error="connection refused" caller=api/proxy.go:58`,
+		}))
+	})
+
+	It("uses the configured keys", func() {
+		opts.LevelKey = "lvl"
+		opts.MessageKey = "msg"
+		opts.ErrorKey = "err"
+		opts.CallerKey = "src"
+		opts.TimeKey = "ts"
+		w = NewWriter(opts)
+		write(`{"lvl":"warn","ts":1452000000,"src":"main.go","err":"timeout","msg":"retrying"}`)
+		Expect(recorder.Texts()).To(Equal([]string{"WARN: retrying error=timeout caller=main.go"}))
+	})
+
+	It("writes events without a level without a severity prefix", func() {
+		write(`{"message":"listening","port":8080}`, `{"level":"","message":"ready"}`)
+		Expect(recorder.Texts()).To(Equal([]string{"listening port=8080", "ready"}))
+	})
+
+	It("writes lines that are not JSON objects unchanged", func() {
+		write(`plain text`, `{"level":"info"`, `[1,2]`)
+		Expect(recorder.Texts()).To(Equal([]string{`plain text`, `{"level":"info"`, `[1,2]`}))
+	})
+
+	It("writes each line as a debug message", func() {
+		write("{\"message\":\"one\"}\n\n{\"message\":\"two\"}")
+		Expect(recorder.Texts()).To(Equal([]string{"one", "two"}))
+	})
+})
+
+var _ = Describe("Level", func() {
+	It("maps the levels that procmon does not name", func() {
+		for name, want := range map[string]procmon.Level{
+			"trace": logfmt.LevelTrace,
+			"panic": logfmt.LevelPanic,
+			"fatal": logfmt.LevelFatal,
+			"warn":  procmon.LevelWarn,
+		} {
+			l, ok := Level(name)
+			Expect(ok).To(BeTrue())
+			Expect(l).To(Equal(want))
+		}
+	})
+
+	It("returns false for events without a level and unknown levels", func() {
+		_, ok := Level("")
+		Expect(ok).To(BeFalse())
+		_, ok = Level("loud")
+		Expect(ok).To(BeFalse())
+	})
+})